@@ -1,7 +1,10 @@
 package config
 
 import (
+	"curriculum-tracker/utils"
+	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,24 +12,62 @@ import (
 )
 
 type Config struct {
-	DatabaseURL    string
-	JWTSecret      string
-	Port           string
-	TokenDuration  time.Duration
-	AllowedOrigins []string
-	Environment    string
+	DatabaseURL          string
+	JWTSecret            string
+	Port                 string
+	AccessTokenDuration  time.Duration
+	RefreshTokenDuration time.Duration
+	AllowedOrigins       []string
+	TrustedProxies       int
+	MailFrom             string
+	MailDir              string
+	PasswordResetURL     string
+	PasswordResetTTL     time.Duration
+	EmailVerificationURL string
+	EmailVerificationTTL time.Duration
+	RequireVerifiedEmail bool
+	AccountDeletionGrace time.Duration
+	LoginMaxFailures     int
+	LoginIPMaxFailures   int
+	LoginBackoffBase     time.Duration
+	LoginBackoffMax      time.Duration
+	LoginLockoutDuration time.Duration
+	LoginFailureWindow   time.Duration
+	RateLimitStore       string
+	PublicRateLimit      utils.RateLimit
+	APIRateLimit         utils.RateLimit
+	Environment          string
 }
 
 func Load() *Config {
 	godotenv.Load()
 
 	return &Config{
-		DatabaseURL:    getEnv("DATABASE_URL", "postgres://localhost/curriculum_tracker?sslmode=disable"),
-		JWTSecret:      getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
-		Port:           getEnv("PORT", "8080"),
-		TokenDuration:  24 * time.Hour,
-		AllowedOrigins: parseAllowedOrigins(getEnv("ALLOWED_ORIGINS", "http://localhost:3000")),
-		Environment:    getEnv("ENVIRONMENT", "development"),
+		DatabaseURL:          getEnv("DATABASE_URL", "postgres://localhost/curriculum_tracker?sslmode=disable"),
+		JWTSecret:            getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+		Port:                 getEnv("PORT", "8080"),
+		AccessTokenDuration:  getDurationEnv("ACCESS_TOKEN_DURATION", 15*time.Minute),
+		RefreshTokenDuration: getDurationEnv("REFRESH_TOKEN_DURATION", 30*24*time.Hour),
+		AllowedOrigins:       parseAllowedOrigins(getEnv("ALLOWED_ORIGINS", "http://localhost:3000")),
+		TrustedProxies:       getTrustedProxies(),
+		MailFrom:             getEnv("MAIL_FROM", "Curriculum Tracker <no-reply@localhost>"),
+		MailDir:              os.Getenv("MAIL_DIR"),
+		PasswordResetURL:     getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+		PasswordResetTTL:     getDurationEnv("PASSWORD_RESET_TTL", time.Hour),
+		EmailVerificationURL: getEnv("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email"),
+		EmailVerificationTTL: getDurationEnv("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		RequireVerifiedEmail: getEnv("REQUIRE_EMAIL_VERIFICATION", "false") == "true",
+		AccountDeletionGrace: getDurationEnv("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+		LoginMaxFailures:     getIntEnv("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:   getIntEnv("LOGIN_IP_MAX_FAILURES", 20),
+		LoginBackoffBase:     getDurationEnv("LOGIN_BACKOFF_BASE", time.Second),
+		LoginBackoffMax:      getDurationEnv("LOGIN_BACKOFF_MAX", time.Minute),
+		LoginLockoutDuration: getDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginFailureWindow:   getDurationEnv("LOGIN_FAILURE_WINDOW", time.Hour),
+		RateLimitStore:       getEnv("RATE_LIMIT_STORE", "memory"),
+		PublicRateLimit:      getRateLimitEnv("RATE_LIMIT_PUBLIC", "20/1m"),
+		APIRateLimit:         getRateLimitEnv("RATE_LIMIT_API", "300/1m"),
+		Environment:          getEnv("ENVIRONMENT", "development"),
 	}
 }
 
@@ -37,6 +78,67 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getDurationEnv reads a duration such as "15m" or "720h", falling back to
+// defaultValue when the variable is unset or invalid.
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// getIntEnv reads a positive integer, falling back to defaultValue when the
+// variable is unset or invalid.
+func getIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("invalid %s %q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// getTrustedProxies reads TRUSTED_PROXIES, the number of reverse proxies in
+// front of the API. TRUST_PROXY_HEADERS=true, from before the number could
+// be set, means one.
+func getTrustedProxies() int {
+	value := os.Getenv("TRUSTED_PROXIES")
+	if value == "" {
+		if getEnv("TRUST_PROXY_HEADERS", "false") == "true" {
+			return 1
+		}
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("invalid TRUSTED_PROXIES %q, using 0", value)
+		return 0
+	}
+	return n
+}
+
+// getRateLimitEnv reads a rate limit such as "100/1m" or "off", falling back
+// to defaultValue when the variable is unset or invalid.
+func getRateLimitEnv(key, defaultValue string) utils.RateLimit {
+	value := getEnv(key, defaultValue)
+	limit, err := utils.ParseRateLimit(value)
+	if err != nil {
+		log.Printf("invalid %s %q, using %s", key, value, defaultValue)
+		limit, _ = utils.ParseRateLimit(defaultValue)
+	}
+	return limit
+}
+
 func parseAllowedOrigins(origins string) []string {
 	if origins == "" {
 		return []string{"http://localhost:3000"}
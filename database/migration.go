@@ -1,8 +1,14 @@
 package database
 
 import (
+	"curriculum-tracker/models"
+	"curriculum-tracker/utils"
 	"database/sql"
 	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
 func RunMigrations(db *sql.DB) error {
@@ -14,6 +20,29 @@ func RunMigrations(db *sql.DB) error {
 		createNotesTable,
 		createTimeEntriesTable,
 		createIndexes,
+		createAuditLogTable,
+		addProjectEstimateColumns,
+		createProjectIdentifierCounters,
+		addProjectBranchGroupColumn,
+		createProjectPrerequisitesTables,
+		addCurriculumTemplateColumn,
+		createCurriculumMembersTable,
+		addUserRoleColumn,
+		createSessionsTables,
+		addSessionClientColumns,
+		createPasswordResetTokensTable,
+		createLoginThrottlesTable,
+		createRateLimitBucketsTable,
+		createPersonalAccessTokensTable,
+		addAccessTokenScopesColumn,
+		addAccountLifecycleColumns,
+		createEmailVerificationTokensTable,
+	}
+
+	// Must run before createProjectIdentifierCounters, whose unique index
+	// cannot be built while duplicates remain
+	if err := renumberDuplicateIdentifiers(db); err != nil {
+		return fmt.Errorf("identifier renumbering failed: %w", err)
 	}
 
 	for i, migration := range migrations {
@@ -22,6 +51,311 @@ func RunMigrations(db *sql.DB) error {
 		}
 	}
 
+	if err := backfillProjectEstimates(db); err != nil {
+		return fmt.Errorf("estimate backfill failed: %w", err)
+	}
+
+	if err := migrateProjectPrerequisites(db); err != nil {
+		return fmt.Errorf("prerequisite migration failed: %w", err)
+	}
+
+	if err := migrateAccessTokenScopes(db); err != nil {
+		return fmt.Errorf("access token scope migration failed: %w", err)
+	}
+
+	return nil
+}
+
+// renumberDuplicateIdentifiers gives fresh identifiers to the projects that
+// share one with an older project in the same curriculum, which concurrent
+// creates could cause before identifiers came from counters. The oldest
+// project keeps the identifier; the others get the next free number for its
+// prefix, so the second B3 becomes e.g. B7 and a second BT becomes BT2.
+// Prerequisites naming a duplicated identifier matched every project with
+// it, so they gain the new identifiers to keep gating the same projects.
+// Once the unique index exists there is nothing to do.
+func renumberDuplicateIdentifiers(db *sql.DB) error {
+	var pending bool
+	err := db.QueryRow(`
+		SELECT to_regclass('projects') IS NOT NULL
+			AND to_regclass('idx_projects_curriculum_identifier') IS NULL
+	`).Scan(&pending)
+	if err != nil {
+		return err
+	}
+	if !pending {
+		return nil
+	}
+
+	var hasPrerequisites bool
+	err = db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'projects' AND column_name = 'prerequisites'
+		)
+	`).Scan(&hasPrerequisites)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT p.id, p.curriculum_id, p.identifier
+		FROM projects p
+		WHERE EXISTS (
+			SELECT 1 FROM projects older
+			WHERE older.curriculum_id = p.curriculum_id AND older.identifier = p.identifier AND older.id < p.id
+		)
+		ORDER BY p.id
+	`)
+	if err != nil {
+		return err
+	}
+
+	type duplicate struct {
+		id           int
+		curriculumID int
+		identifier   string
+	}
+
+	var duplicates []duplicate
+	for rows.Next() {
+		var d duplicate
+		if err := rows.Scan(&d.id, &d.curriculumID, &d.identifier); err != nil {
+			rows.Close()
+			return err
+		}
+		duplicates = append(duplicates, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range duplicates {
+		prefix := strings.TrimRight(d.identifier, "0123456789")
+
+		// A bare prefix such as BT counts as number 1
+		var last int
+		err := tx.QueryRow(`
+			SELECT COALESCE(MAX(COALESCE(NULLIF(substring(identifier FROM length($2) + 1), '')::integer, 1)), 0)
+			FROM projects
+			WHERE curriculum_id = $1 AND left(identifier, length($2)) = $2
+				AND substring(identifier FROM length($2) + 1) ~ '^[0-9]*$'
+		`, d.curriculumID, prefix).Scan(&last)
+		if err != nil {
+			return err
+		}
+		identifier := fmt.Sprintf("%s%d", prefix, last+1)
+
+		if _, err := tx.Exec(`UPDATE projects SET identifier = $1 WHERE id = $2`, identifier, d.id); err != nil {
+			return err
+		}
+
+		if hasPrerequisites {
+			_, err = tx.Exec(`
+				UPDATE projects SET prerequisites = array_append(prerequisites, $1)
+				WHERE curriculum_id = $2 AND id <> $3 AND $4 = ANY(prerequisites)
+			`, identifier, d.curriculumID, d.id, d.identifier)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if len(duplicates) > 0 {
+		log.Printf("Renumbered %d projects with duplicate identifiers", len(duplicates))
+	}
+
+	return nil
+}
+
+// migrateAccessTokenScopes turns the legacy personal_access_tokens.scope
+// column into scopes: read tokens get every read scope and write tokens
+// every scope, which is what they allowed before. The column is then dropped.
+func migrateAccessTokenScopes(db *sql.DB) error {
+	var hasColumn bool
+	err := db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'personal_access_tokens' AND column_name = 'scope'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE personal_access_tokens
+		SET scopes = CASE scope WHEN 'read' THEN $1::text[] ELSE $2::text[] END
+	`, pq.Array(models.ReadScopes), pq.Array(models.AllScopes))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`ALTER TABLE personal_access_tokens DROP COLUMN scope`); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrateProjectPrerequisites moves prerequisites from the legacy
+// projects.prerequisites TEXT[] column into the project_prerequisites and
+// project_prerequisite_groups tables, then drops the column. References to
+// identifiers that no longer exist cannot be migrated and are logged.
+func migrateProjectPrerequisites(db *sql.DB) error {
+	var hasColumn bool
+	err := db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'projects' AND column_name = 'prerequisites'
+		)
+	`).Scan(&hasColumn)
+	if err != nil {
+		return err
+	}
+	if !hasColumn {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO project_prerequisites (project_id, prerequisite_id)
+		SELECT DISTINCT p.id, prereq.id
+		FROM projects p
+		CROSS JOIN unnest(p.prerequisites) AS ref
+		JOIN projects prereq ON prereq.curriculum_id = p.curriculum_id AND prereq.identifier = ref
+		ON CONFLICT DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO project_prerequisite_groups (project_id, project_type, branch_group)
+		SELECT DISTINCT p.id, member.project_type, member.branch_group
+		FROM projects p
+		CROSS JOIN unnest(p.prerequisites) AS ref
+		JOIN projects member ON member.curriculum_id = p.curriculum_id
+			AND member.branch_group IS NOT NULL
+			AND member.identifier LIKE ref || '\_%'
+		WHERE NOT EXISTS (
+			SELECT 1 FROM projects exact
+			WHERE exact.curriculum_id = p.curriculum_id AND exact.identifier = ref
+		)
+		ON CONFLICT DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+
+	var dropped int
+	err = tx.QueryRow(`
+		SELECT COUNT(*)
+		FROM projects p
+		CROSS JOIN unnest(p.prerequisites) AS ref
+		WHERE NOT EXISTS (
+			SELECT 1 FROM projects prereq
+			WHERE prereq.curriculum_id = p.curriculum_id
+			AND (prereq.identifier = ref OR (prereq.branch_group IS NOT NULL AND prereq.identifier LIKE ref || '\_%'))
+		)
+	`).Scan(&dropped)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`ALTER TABLE projects DROP COLUMN prerequisites`); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if dropped > 0 {
+		log.Printf("Dropped %d prerequisite references to projects that no longer exist", dropped)
+	}
+
+	return nil
+}
+
+// backfillProjectEstimates parses the free-form estimated_time of projects
+// created before estimates were stored as minute ranges. Rows that cannot be
+// parsed keep NULL ranges and are picked up again on the next start.
+func backfillProjectEstimates(db *sql.DB) error {
+	rows, err := db.Query(`
+		SELECT id, estimated_time
+		FROM projects
+		WHERE estimated_minutes_min IS NULL AND COALESCE(TRIM(estimated_time), '') <> ''
+	`)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		id            int
+		estimatedTime string
+	}
+
+	var projects []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.estimatedTime); err != nil {
+			rows.Close()
+			return err
+		}
+		projects = append(projects, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	unparsed := 0
+	for _, p := range projects {
+		minMinutes, maxMinutes, err := utils.ParseEstimatedTime(p.estimatedTime)
+		if err != nil {
+			unparsed++
+			continue
+		}
+
+		_, err = db.Exec(
+			`UPDATE projects SET estimated_minutes_min = $1, estimated_minutes_max = $2 WHERE id = $3`,
+			minMinutes, maxMinutes, p.id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if unparsed > 0 {
+		log.Printf("%d project estimates could not be parsed and were left unstructured", unparsed)
+	}
+
 	return nil
 }
 
@@ -56,7 +390,6 @@ CREATE TABLE IF NOT EXISTS projects (
 	description TEXT,
 	learning_objectives TEXT[],
 	estimated_time VARCHAR(100),
-	prerequisites TEXT[],
 	project_type VARCHAR(50) NOT NULL,
 	position_order INTEGER NOT NULL DEFAULT 0,
 	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
@@ -115,3 +448,216 @@ CREATE INDEX IF NOT EXISTS idx_time_entries_user_id ON time_entries(user_id);
 CREATE INDEX IF NOT EXISTS idx_time_entries_project_id ON time_entries(project_id);
 CREATE INDEX IF NOT EXISTS idx_time_entries_date ON time_entries(date);
 `
+
+const createAuditLogTable = `
+CREATE TABLE IF NOT EXISTS audit_log (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	action VARCHAR(100) NOT NULL,
+	entity_type VARCHAR(50) NOT NULL,
+	entity_id INTEGER,
+	details JSONB,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+`
+
+const addProjectEstimateColumns = `
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS estimated_minutes_min INTEGER;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS estimated_minutes_max INTEGER;
+`
+
+const createProjectIdentifierCounters = `
+CREATE TABLE IF NOT EXISTS project_identifier_counters (
+	curriculum_id INTEGER NOT NULL REFERENCES curricula(id) ON DELETE CASCADE,
+	counter_key VARCHAR(100) NOT NULL,
+	last_value INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (curriculum_id, counter_key)
+);
+
+-- Seeded from the identifiers rather than project_type, since projects
+-- could change type and keep their identifier: a base B3 retyped to root
+-- still holds B3, so the base counter must start past it
+INSERT INTO project_identifier_counters (curriculum_id, counter_key, last_value)
+SELECT p.curriculum_id, k.counter_key, MAX(substring(p.identifier FROM length(k.prefix) + 1)::integer)
+FROM projects p
+JOIN (VALUES
+	('root', 'R'), ('base', 'B'), ('lowerBranch', 'LB'),
+	('middleBranch', 'MB'), ('upperBranch', 'UB'), ('flowerMilestone', 'F')
+) AS k(counter_key, prefix) ON p.identifier ~ ('^' || k.prefix || '[0-9]+$')
+GROUP BY p.curriculum_id, k.counter_key
+ON CONFLICT (curriculum_id, counter_key)
+DO UPDATE SET last_value = GREATEST(project_identifier_counters.last_value, EXCLUDED.last_value);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_curriculum_identifier ON projects(curriculum_id, identifier);
+`
+
+const addProjectBranchGroupColumn = `
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS branch_group INTEGER;
+`
+
+const createProjectPrerequisitesTables = `
+CREATE TABLE IF NOT EXISTS project_prerequisites (
+	project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	prerequisite_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	PRIMARY KEY (project_id, prerequisite_id)
+);
+
+CREATE TABLE IF NOT EXISTS project_prerequisite_groups (
+	project_id INTEGER NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	project_type VARCHAR(50) NOT NULL,
+	branch_group INTEGER NOT NULL,
+	PRIMARY KEY (project_id, project_type, branch_group)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_prerequisites_prerequisite_id ON project_prerequisites(prerequisite_id);
+`
+
+const addCurriculumTemplateColumn = `
+ALTER TABLE curricula ADD COLUMN IF NOT EXISTS is_template BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_curricula_is_template ON curricula(is_template) WHERE is_template;
+`
+
+const createCurriculumMembersTable = `
+CREATE TABLE IF NOT EXISTS curriculum_members (
+	curriculum_id INTEGER NOT NULL REFERENCES curricula(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'learner')),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (curriculum_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_curriculum_members_user_id ON curriculum_members(user_id);
+
+INSERT INTO curriculum_members (curriculum_id, user_id, role)
+SELECT id, user_id, 'owner'
+FROM curricula
+ON CONFLICT (curriculum_id, user_id) DO NOTHING;
+`
+
+const addUserRoleColumn = `
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'learner'
+	CHECK (role IN ('admin', 'mentor', 'learner'));
+`
+
+// Refresh tokens are stored as SHA-256 hashes. previous_token_hash keeps the
+// token that was rotated out last, so presenting it again can be detected as
+// reuse and the session revoked.
+const createSessionsTables = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	refresh_token_hash VARCHAR(64) NOT NULL UNIQUE,
+	previous_token_hash VARCHAR(64),
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_token_hash ON sessions(previous_token_hash);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti VARCHAR(64) PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL
+);
+`
+
+const addSessionClientColumns = `
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45) NOT NULL DEFAULT '';
+`
+
+// Reset tokens are stored as SHA-256 hashes like refresh tokens. used_at is
+// set once a token has been redeemed so it cannot be used twice.
+const createPasswordResetTokensTable = `
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	token_hash VARCHAR(64) NOT NULL UNIQUE,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+`
+
+// Failed logins per account ("account:<email>") and per client IP
+// ("ip:<address>"). Times are written in UTC by the application.
+const createLoginThrottlesTable = `
+CREATE TABLE IF NOT EXISTS login_throttles (
+	throttle_key TEXT PRIMARY KEY,
+	failures INTEGER NOT NULL DEFAULT 0,
+	last_failure_at TIMESTAMP NOT NULL,
+	blocked_until TIMESTAMP NOT NULL
+);
+`
+
+// Token buckets for the Postgres rate limit store. A bucket is full again
+// at full_at, after which it can be deleted.
+const createRateLimitBucketsTable = `
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+	bucket_key TEXT PRIMARY KEY,
+	tokens DOUBLE PRECISION NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	full_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_full_at ON rate_limit_buckets(full_at);
+`
+
+// Personal access tokens are stored as SHA-256 hashes. token_prefix keeps
+// the start of the token so its owner can tell their tokens apart.
+const createPersonalAccessTokensTable = `
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL,
+	token_prefix VARCHAR(20) NOT NULL,
+	token_hash VARCHAR(64) NOT NULL UNIQUE,
+	scope VARCHAR(10) NOT NULL CHECK (scope IN ('read', 'write')),
+	expires_at TIMESTAMP,
+	last_used_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+`
+
+// Scopes replace the read/write scope personal access tokens started with;
+// migrateAccessTokenScopes carries existing tokens over.
+const addAccessTokenScopesColumn = `
+ALTER TABLE personal_access_tokens ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}';
+`
+
+// Accounts that existed before email verification count as verified: adding
+// the column with a default fills in existing rows, and dropping the default
+// leaves new accounts unverified. deletion_requested_at is set while an
+// account waits out its deletion grace period.
+const addAccountLifecycleColumns = `
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ALTER COLUMN email_verified_at DROP DEFAULT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_requested_at ON users(deletion_requested_at);
+`
+
+// Verification tokens are stored as SHA-256 hashes and remember the address
+// they were sent to, so a token stops working if the email changes again.
+const createEmailVerificationTokensTable = `
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	email VARCHAR(255) NOT NULL,
+	token_hash VARCHAR(64) NOT NULL UNIQUE,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);
+`
@@ -0,0 +1,174 @@
+package database_test
+
+import (
+	"curriculum-tracker/database"
+	"curriculum-tracker/internal/testdb"
+	"curriculum-tracker/models"
+	"curriculum-tracker/services"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// legacySchema is the schema from before project identifiers came from
+// counters, when concurrent creates could give two projects the same one.
+const legacySchema = `
+CREATE TABLE users (
+	id SERIAL PRIMARY KEY,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	password_hash VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE curricula (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	description TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE projects (
+	id SERIAL PRIMARY KEY,
+	curriculum_id INTEGER NOT NULL REFERENCES curricula(id) ON DELETE CASCADE,
+	identifier VARCHAR(50),
+	name VARCHAR(255) NOT NULL,
+	description TEXT,
+	learning_objectives TEXT[],
+	estimated_time VARCHAR(100),
+	prerequisites TEXT[],
+	project_type VARCHAR(50) NOT NULL,
+	position_order INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+func TestRunMigrationsRenumbersDuplicateIdentifiers(t *testing.T) {
+	db := testdb.OpenSchema(t)
+
+	if _, err := db.Exec(legacySchema); err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	var userID, curriculumID, otherCurriculumID int
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, name) VALUES ('legacy@example.com', '', 'Legacy') RETURNING id
+	`).Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	for _, id := range []*int{&curriculumID, &otherCurriculumID} {
+		err := db.QueryRow(`INSERT INTO curricula (user_id, name) VALUES ($1, 'Legacy') RETURNING id`, userID).Scan(id)
+		if err != nil {
+			t.Fatalf("failed to create curriculum: %v", err)
+		}
+	}
+
+	legacyProjects := []struct {
+		curriculumID  int
+		identifier    string
+		projectType   string
+		prerequisites []string
+	}{
+		{curriculumID, "R1", models.ProjectTypeRoot, nil},
+		{curriculumID, "B1", models.ProjectTypeBase, []string{"R1"}},
+		{curriculumID, "B2", models.ProjectTypeBase, []string{"B1"}},
+		{curriculumID, "B2", models.ProjectTypeBase, []string{"B1"}},
+		{curriculumID, "B3", models.ProjectTypeBase, nil},
+		{curriculumID, "B3", models.ProjectTypeBase, nil},
+		{curriculumID, "BT", models.ProjectTypeBaseTest, nil},
+		{curriculumID, "BT", models.ProjectTypeBaseTest, nil},
+		{curriculumID, "F1", models.ProjectTypeFlowerMilestone, []string{"B2", "B3"}},
+		{otherCurriculumID, "B2", models.ProjectTypeBase, nil},
+		// Retyped after creation, so they keep a base identifier
+		{otherCurriculumID, "B3", models.ProjectTypeRoot, nil},
+		{otherCurriculumID, "B4", models.ProjectTypeBaseTest, nil},
+	}
+
+	ids := make([]int, len(legacyProjects))
+	for i, p := range legacyProjects {
+		err := db.QueryRow(`
+			INSERT INTO projects (curriculum_id, identifier, name, prerequisites, project_type, position_order)
+			VALUES ($1, $2, $2, $3, $4, $5)
+			RETURNING id
+		`, p.curriculumID, p.identifier, pq.Array(p.prerequisites), p.projectType, i).Scan(&ids[i])
+		if err != nil {
+			t.Fatalf("failed to create project: %v", err)
+		}
+	}
+
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("RunMigrations failed: %v", err)
+	}
+
+	// Duplicates are renumbered in id order; the oldest keeps its identifier
+	want := []string{"R1", "B1", "B2", "B4", "B3", "B5", "BT", "BT2", "F1", "B2", "B3", "B4"}
+	for i, id := range ids {
+		var identifier string
+		if err := db.QueryRow(`SELECT identifier FROM projects WHERE id = $1`, id).Scan(&identifier); err != nil {
+			t.Fatalf("failed to query project: %v", err)
+		}
+		if identifier != want[i] {
+			t.Errorf("project %d identifier = %s, want %s", i, identifier, want[i])
+		}
+	}
+
+	// F1 named B2 and B3, which matched both copies of each
+	rows, err := db.Query(`
+		SELECT prereq.identifier
+		FROM project_prerequisites pp
+		JOIN projects prereq ON prereq.id = pp.prerequisite_id
+		WHERE pp.project_id = $1
+		ORDER BY prereq.identifier
+	`, ids[8])
+	if err != nil {
+		t.Fatalf("failed to query prerequisites: %v", err)
+	}
+	defer rows.Close()
+
+	prerequisites := make([]string, 0)
+	for rows.Next() {
+		var identifier string
+		if err := rows.Scan(&identifier); err != nil {
+			t.Fatalf("failed to scan prerequisite: %v", err)
+		}
+		prerequisites = append(prerequisites, identifier)
+	}
+	if want := []string{"B2", "B3", "B4", "B5"}; !reflect.DeepEqual(prerequisites, want) {
+		t.Errorf("F1 prerequisites = %v, want %v", prerequisites, want)
+	}
+
+	// The counters are seeded from the renumbered identifiers
+	project, err := services.NewProjectService(db).CreateProject(userID, curriculumID, models.CreateProjectRequest{
+		Name:        "Next",
+		ProjectType: models.ProjectTypeBase,
+	})
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if project.Identifier != "B6" {
+		t.Errorf("next identifier = %s, want B6", project.Identifier)
+	}
+
+	// Identifiers held by retyped projects count towards their prefix
+	project, err = services.NewProjectService(db).CreateProject(userID, otherCurriculumID, models.CreateProjectRequest{
+		Name:        "Next",
+		ProjectType: models.ProjectTypeBase,
+	})
+	if err != nil {
+		t.Fatalf("CreateProject failed: %v", err)
+	}
+	if project.Identifier != "B5" {
+		t.Errorf("next identifier in the retyped curriculum = %s, want B5", project.Identifier)
+	}
+
+	// Migrations run on every start
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("second RunMigrations failed: %v", err)
+	}
+}
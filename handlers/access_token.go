@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"curriculum-tracker/models"
+	"curriculum-tracker/services"
+	"curriculum-tracker/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *AuthHandler) GetAccessTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.accessTokens.ListAccessTokens(claims.UserID)
+	if err != nil {
+		log.Printf("error listing access tokens: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch access tokens")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAccessTokenRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.Name == "" || (len(req.Scopes) == 0 && req.Scope == "") {
+		utils.WriteError(w, http.StatusBadRequest, "Name and scopes are required")
+		return
+	}
+
+	accessToken, token, err := h.accessTokens.CreateAccessToken(claims.UserID, req)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		log.Printf("error creating access token: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to create access token")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, models.CreateAccessTokenResponse{
+		AccessToken: *accessToken,
+		Token:       token,
+	})
+}
+
+func (h *AuthHandler) DeleteAccessToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	tokenID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	err = h.accessTokens.DeleteAccessToken(claims.UserID, tokenID)
+	if err != nil {
+		if errors.Is(err, services.ErrAccessTokenNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "Access token not found")
+			return
+		}
+		log.Printf("error deleting access token: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to delete access token")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Access token deleted successfully"})
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"curriculum-tracker/mailer"
+	"curriculum-tracker/models"
+	"curriculum-tracker/services"
+	"curriculum-tracker/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.VerifyEmailRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.Token == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	err := h.authService.VerifyEmail(req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid or expired verification token")
+			return
+		}
+		log.Printf("error verifying email: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Email verified successfully"})
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.sendVerification(claims.UserID); err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		log.Printf("error sending verification: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Verification email sent"})
+}
+
+func (h *AuthHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.Name == nil && req.Email == nil {
+		utils.WriteError(w, http.StatusBadRequest, "Name or email is required")
+		return
+	}
+
+	user, previousEmail, err := h.authService.UpdateProfile(claims.UserID, req)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			utils.WriteError(w, http.StatusConflict, "Email already exists")
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		log.Printf("error updating profile: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	if previousEmail != "" {
+		if err := h.sendVerification(user.ID); err != nil {
+			log.Printf("error sending verification: %v", err)
+		}
+		if err := h.mailer.Send(h.emailChangedMessage(user, previousEmail)); err != nil {
+			log.Printf("error sending email change notice: %v", err)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, user)
+}
+
+func (h *AuthHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.Password == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Password is required")
+		return
+	}
+
+	deletionDue, err := h.authService.RequestAccountDeletion(claims.UserID, req.Password, h.config.AccountDeletionGrace)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		log.Printf("error requesting account deletion: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, models.DeleteAccountResponse{
+		Message:     "Account scheduled for deletion; sign in before then to cancel",
+		DeletionDue: deletionDue,
+	})
+}
+
+// sendVerification emails the user a link to verify their current address.
+func (h *AuthHandler) sendVerification(userID int) error {
+	user, token, err := h.authService.CreateEmailVerification(userID, h.config.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := h.config.EmailVerificationURL + "?token=" + url.QueryEscape(token)
+
+	body := fmt.Sprintf(`Hi %s,
+
+Please confirm that this is your email address for Curriculum Tracker by
+opening this link within %s:
+
+%s
+
+If you did not use this address for Curriculum Tracker, you can ignore this email.
+`, user.Name, h.config.EmailVerificationTTL, link)
+
+	return h.mailer.Send(mailer.Message{
+		From:    h.config.MailFrom,
+		To:      user.Email,
+		Subject: "Verify your Curriculum Tracker email address",
+		Body:    body,
+	})
+}
+
+func (h *AuthHandler) emailChangedMessage(user *models.User, previousEmail string) mailer.Message {
+	body := fmt.Sprintf(`Hi %s,
+
+The email address for your Curriculum Tracker account was changed from
+%s to %s.
+
+If you did not make this change, reset your password and contact support.
+`, user.Name, previousEmail, user.Email)
+
+	return mailer.Message{
+		From:    h.config.MailFrom,
+		To:      previousEmail,
+		Subject: "Your Curriculum Tracker email address was changed",
+		Body:    body,
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"curriculum-tracker/middleware"
+	"curriculum-tracker/models"
+	"curriculum-tracker/services"
+	"curriculum-tracker/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler serves the admin-only routes. Access is enforced by
+// middleware.RequireRole on the route group, not by the handlers.
+type AdminHandler struct {
+	authService *services.AuthService
+}
+
+func NewAdminHandler(authService *services.AuthService) *AdminHandler {
+	return &AdminHandler{authService: authService}
+}
+
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	users, err := h.authService.ListUsers()
+	if err != nil {
+		log.Printf("error listing users: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch users")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, users)
+}
+
+func (h *AdminHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	adminID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req models.UpdateUserRoleRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	user, err := h.authService.UpdateUserRole(adminID, userID, req)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error updating user role: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to update user role")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, user)
+}
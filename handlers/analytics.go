@@ -5,6 +5,7 @@ import (
 	"curriculum-tracker/models"
 	"curriculum-tracker/services"
 	"curriculum-tracker/utils"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -13,12 +14,14 @@ import (
 )
 
 type AnalyticsHandler struct {
-	analyticsService *services.AnalyticsService
+	analyticsService  *services.AnalyticsService
+	curriculumService *services.CurriculumService
 }
 
-func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService, curriculumService *services.CurriculumService) *AnalyticsHandler {
 	return &AnalyticsHandler{
-		analyticsService: analyticsService,
+		analyticsService:  analyticsService,
+		curriculumService: curriculumService,
 	}
 }
 
@@ -52,7 +55,16 @@ func (h *AnalyticsHandler) CreateTimeEntry(w http.ResponseWriter, r *http.Reques
 
 	timeEntry, err := h.analyticsService.CreateTimeEntry(userID, req)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "Failed to create time entry: "+err.Error())
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error creating time entry: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to create time entry")
 		return
 	}
 
@@ -80,6 +92,9 @@ func (h *AnalyticsHandler) GetProjectTimeEntries(w http.ResponseWriter, r *http.
 
 	timeEntries, err := h.analyticsService.GetTimeEntriesByProjectID(userID, projectID)
 	if err != nil {
+		if writeAccessError(w, err) {
+			return
+		}
 		log.Printf("error getting time entries: %v", err)
 		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch time entries")
 		return
@@ -109,6 +124,9 @@ func (h *AnalyticsHandler) GetCurriculumTimeStats(w http.ResponseWriter, r *http
 
 	stats, err := h.analyticsService.GetTimeStatsByCurriculumID(userID, curriculumID)
 	if err != nil {
+		if writeAccessError(w, err) {
+			return
+		}
 		log.Printf("error getting time stats: %v", err)
 		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch time stats")
 		return
@@ -138,3 +156,112 @@ func (h *AnalyticsHandler) GetUserStats(w http.ResponseWriter, r *http.Request)
 
 	utils.WriteJSON(w, http.StatusOK, stats)
 }
+
+func (h *AnalyticsHandler) GetCurriculumForecast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	if _, err := h.curriculumService.GetCurriculumByID(userID, curriculumID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Curriculum not found")
+		return
+	}
+
+	forecast, err := h.analyticsService.GetCurriculumForecast(userID, curriculumID)
+	if err != nil {
+		log.Printf("error getting curriculum forecast: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch curriculum forecast")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, forecast)
+}
+
+func (h *AnalyticsHandler) GetCurriculumVariance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	if _, err := h.curriculumService.GetCurriculumByID(userID, curriculumID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Curriculum not found")
+		return
+	}
+
+	report, err := h.analyticsService.GetCurriculumVariance(userID, curriculumID)
+	if err != nil {
+		log.Printf("error getting curriculum variance: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch curriculum variance")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, report)
+}
+
+func (h *AnalyticsHandler) GetCurriculumCohort(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	stalledDays := services.DefaultStalledDays
+	if value := r.URL.Query().Get("stalled_days"); value != "" {
+		stalledDays, err = strconv.Atoi(value)
+		if err != nil || stalledDays < 1 {
+			utils.WriteError(w, http.StatusBadRequest, "stalled_days must be a positive integer")
+			return
+		}
+	}
+
+	report, err := h.analyticsService.GetCohortReport(userID, curriculumID, stalledDays)
+	if err != nil {
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error getting cohort report: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch cohort report")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, report)
+}
@@ -2,23 +2,38 @@ package handlers
 
 import (
 	"curriculum-tracker/config"
+	"curriculum-tracker/mailer"
 	"curriculum-tracker/middleware"
 	"curriculum-tracker/models"
 	"curriculum-tracker/services"
 	"curriculum-tracker/utils"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
-	config      *config.Config
+	authService    *services.AuthService
+	sessionService *services.SessionService
+	loginThrottle  *services.LoginThrottleService
+	accessTokens   *services.AccessTokenService
+	mailer         mailer.Mailer
+	config         *config.Config
 }
 
-func NewAuthHandler(authService *services.AuthService, config *config.Config) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, sessionService *services.SessionService, loginThrottle *services.LoginThrottleService, accessTokens *services.AccessTokenService, mailer mailer.Mailer, config *config.Config) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		config:      config,
+		authService:    authService,
+		sessionService: sessionService,
+		loginThrottle:  loginThrottle,
+		accessTokens:   accessTokens,
+		mailer:         mailer,
+		config:         config,
 	}
 }
 
@@ -45,19 +60,27 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email, h.config.JWTSecret, h.config.TokenDuration)
-	if err != nil {
-		log.Printf("error generating token: %v", err)
-		utils.WriteError(w, http.StatusInternalServerError, "Failed to generate token")
+	if err := h.sendVerification(user.ID); err != nil {
+		log.Printf("error sending verification: %v", err)
+	}
+
+	// Accounts that must verify their email first get no session yet
+	if h.config.RequireVerifiedEmail {
+		utils.WriteJSON(w, http.StatusCreated, models.PendingVerificationResponse{
+			Message: "Check your email to verify your address, then log in",
+			User:    *user,
+		})
 		return
 	}
 
-	response := models.LoginResponse{
-		Token: token,
-		User:  *user,
+	session, refreshToken, err := h.sessionService.CreateSession(user.ID, h.sessionClient(r), h.config.RefreshTokenDuration)
+	if err != nil {
+		log.Printf("error creating session: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to create session")
+		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, response)
+	h.writeTokens(w, http.StatusCreated, user, session, refreshToken)
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -77,25 +100,58 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.authService.AuthenticateUser(req.Email, req.Password)
+	ip := utils.ClientIP(r, h.config.TrustedProxies)
+
+	// Reserved before the password is checked, so concurrent guesses are
+	// counted and turned away rather than all being verified
+	wait, err := h.loginThrottle.Reserve(req.Email, ip)
 	if err != nil {
-		utils.WriteError(w, http.StatusUnauthorized, "Invalid credentials")
+		log.Printf("error checking login throttle: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later")
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Email, h.config.JWTSecret, h.config.TokenDuration)
+	user, err := h.authService.AuthenticateUser(req.Email, req.Password)
 	if err != nil {
-		log.Printf("error generating token: %v", err)
-		utils.WriteError(w, http.StatusInternalServerError, "Failed to generate token")
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			if err := h.loginThrottle.RecordFailure(req.Email, ip); err != nil {
+				log.Printf("error recording login failure: %v", err)
+			}
+			utils.WriteError(w, http.StatusUnauthorized, "Invalid credentials")
+			return
+		}
+		log.Printf("error authenticating user: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to log in")
 		return
 	}
 
-	response := models.LoginResponse{
-		Token: token,
-		User:  *user,
+	if err := h.loginThrottle.RecordSuccess(req.Email, ip); err != nil {
+		log.Printf("error clearing login throttle: %v", err)
 	}
 
-	utils.WriteJSON(w, http.StatusOK, response)
+	// The password was right, so sending a fresh link cannot be abused to
+	// mail strangers, and users who lost the first one are not stuck
+	if h.config.RequireVerifiedEmail && !user.EmailVerified {
+		if err := h.sendVerification(user.ID); err != nil {
+			log.Printf("error sending verification: %v", err)
+		}
+		utils.WriteError(w, http.StatusForbidden, "Email address not verified, a new verification link has been sent")
+		return
+	}
+
+	session, refreshToken, err := h.sessionService.CreateSession(user.ID, h.sessionClient(r), h.config.RefreshTokenDuration)
+	if err != nil {
+		log.Printf("error creating session: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	h.writeTokens(w, http.StatusOK, user, session, refreshToken)
 }
 
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
@@ -118,3 +174,187 @@ func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 
 	utils.WriteJSON(w, http.StatusOK, user)
 }
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.RefreshTokenRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.RefreshToken == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Refresh token is required")
+		return
+	}
+
+	session, refreshToken, err := h.sessionService.RotateSession(req.RefreshToken, h.sessionClient(r), h.config.RefreshTokenDuration)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			utils.WriteError(w, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		log.Printf("error refreshing session: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to refresh session")
+		return
+	}
+
+	user, err := h.authService.GetUserByID(session.UserID)
+	if err != nil {
+		log.Printf("error getting user: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to refresh session")
+		return
+	}
+
+	h.writeTokens(w, http.StatusOK, user, session, refreshToken)
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.sessionService.RevokeSession(claims.UserID, claims.SessionID, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		log.Printf("error logging out: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.sessionService.RevokeAllSessions(claims.UserID, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		log.Printf("error logging out all sessions: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Logged out of all sessions"})
+}
+
+func (h *AuthHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		log.Printf("error listing sessions: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	sessionID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	// Revoking the current session also revokes the token used to do it
+	var tokenID string
+	if sessionID == claims.SessionID {
+		tokenID = claims.ID
+	}
+
+	err = h.sessionService.RevokeSession(claims.UserID, sessionID, tokenID, claims.ExpiresAt.Time)
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		log.Printf("error revoking session: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
+
+// requireSession returns the claims of the session making the request.
+// Sessions and credentials cannot be managed with a personal access token,
+// so a leaked one cannot be used to mint more or lock the owner out.
+func requireSession(w http.ResponseWriter, r *http.Request) (*utils.Claims, bool) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusForbidden, "Personal access tokens cannot be used for this endpoint")
+	}
+	return claims, ok
+}
+
+// sessionClient describes the device making a request, for the session
+// list. User agents are truncated since clients control them.
+func (h *AuthHandler) sessionClient(r *http.Request) models.SessionClient {
+	userAgent := r.UserAgent()
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	return models.SessionClient{
+		UserAgent: userAgent,
+		IPAddress: utils.ClientIP(r, h.config.TrustedProxies),
+	}
+}
+
+// writeTokens issues an access token for a session and writes it with the
+// session's refresh token and the user.
+func (h *AuthHandler) writeTokens(w http.ResponseWriter, status int, user *models.User, session *models.Session, refreshToken string) {
+	expiresAt := time.Now().Add(h.config.AccessTokenDuration)
+	token, err := utils.GenerateToken(user.ID, user.Email, user.Role, models.AllScopes, session.ID, h.config.JWTSecret, h.config.AccessTokenDuration)
+	if err != nil {
+		log.Printf("error generating token: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+
+	response := models.LoginResponse{
+		Token:          token,
+		TokenExpiresAt: expiresAt,
+		RefreshToken:   refreshToken,
+		User:           *user,
+	}
+
+	utils.WriteJSON(w, status, response)
+}
@@ -5,9 +5,13 @@ import (
 	"curriculum-tracker/models"
 	"curriculum-tracker/services"
 	"curriculum-tracker/utils"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -47,6 +51,13 @@ func (h *CurriculumHandler) CreateCurriculum(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// Templates are published for everyone, so only mentors and admins may
+	// publish them
+	if req.IsTemplate && !middleware.HasRole(r.Context(), models.UserRoleMentor, models.UserRoleAdmin) {
+		utils.WriteError(w, http.StatusForbidden, "Only mentors can publish templates")
+		return
+	}
+
 	curriculum, err := h.curriculumService.CreateCurriculum(userID, req)
 	if err != nil {
 		log.Printf("error creating curriculum: %v", err)
@@ -150,9 +161,18 @@ func (h *CurriculumHandler) UpdateCurriculum(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if req.IsTemplate != nil && *req.IsTemplate && !middleware.HasRole(r.Context(), models.UserRoleMentor, models.UserRoleAdmin) {
+		utils.WriteError(w, http.StatusForbidden, "Only mentors can publish templates")
+		return
+	}
+
 	curriculum, err := h.curriculumService.UpdateCurriculum(userID, curriculumID, req)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "Curriculum not found")
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error updating curriculum: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to update curriculum")
 		return
 	}
 
@@ -180,9 +200,236 @@ func (h *CurriculumHandler) DeleteCurriculum(w http.ResponseWriter, r *http.Requ
 
 	err = h.curriculumService.DeleteCurriculum(userID, curriculumID)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "Curriculum not found")
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error deleting curriculum: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to delete curriculum")
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Curriculum deleted successfully"})
 }
+
+func (h *CurriculumHandler) GetCurriculumGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	if _, err := h.curriculumService.GetCurriculumByID(userID, curriculumID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Curriculum not found")
+		return
+	}
+
+	graph, err := h.projectService.GetCurriculumGraph(userID, curriculumID)
+	if err != nil {
+		log.Printf("error building curriculum graph: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to build curriculum graph")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, graph)
+}
+
+func (h *CurriculumHandler) GetNextProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	if _, err := h.curriculumService.GetCurriculumByID(userID, curriculumID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Curriculum not found")
+		return
+	}
+
+	next, err := h.projectService.GetNextProjects(userID, curriculumID)
+	if err != nil {
+		log.Printf("error getting next projects: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch next projects")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, next)
+}
+
+func (h *CurriculumHandler) ExportCurriculum(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = utils.FormatJSON
+	}
+	if format != utils.FormatJSON && format != utils.FormatYAML {
+		utils.WriteError(w, http.StatusBadRequest, "Format must be json or yaml")
+		return
+	}
+
+	var opts models.ExportOptions
+	if include := r.URL.Query().Get("include"); include != "" {
+		for _, part := range strings.Split(include, ",") {
+			switch strings.TrimSpace(part) {
+			case "progress":
+				opts.IncludeProgress = true
+			case "notes":
+				opts.IncludeNotes = true
+			case "time_entries":
+				opts.IncludeTimeEntries = true
+			default:
+				utils.WriteError(w, http.StatusBadRequest, "Include must list progress, notes or time_entries")
+				return
+			}
+		}
+	}
+
+	if _, err := h.curriculumService.GetCurriculumByID(userID, curriculumID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, "Curriculum not found")
+		return
+	}
+
+	doc, err := h.curriculumService.ExportCurriculum(userID, curriculumID, opts)
+	if err != nil {
+		log.Printf("error exporting curriculum: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to export curriculum")
+		return
+	}
+
+	if err := utils.WriteDocument(w, format, fmt.Sprintf("curriculum-%d", curriculumID), doc); err != nil {
+		log.Printf("error writing curriculum export: %v", err)
+	}
+}
+
+func (h *CurriculumHandler) ImportCurriculum(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var doc models.CurriculumDocument
+	if err := utils.ParseDocument(r, &doc); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid document")
+		return
+	}
+
+	result, err := h.curriculumService.ImportCurriculum(userID, doc)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		log.Printf("error importing curriculum: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to import curriculum")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, result)
+}
+
+func (h *CurriculumHandler) CloneCurriculum(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	// The body is optional; an empty one clones into the caller's account
+	var req models.CloneCurriculumRequest
+	if err := utils.ParseJSON(r, &req); err != nil && err != io.EOF {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	curriculum, err := h.curriculumService.CloneCurriculum(userID, curriculumID, req)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error cloning curriculum: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to clone curriculum")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, curriculum)
+}
+
+func (h *CurriculumHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	templates, err := h.curriculumService.GetTemplates()
+	if err != nil {
+		log.Printf("error getting templates: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to get templates")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, templates)
+}
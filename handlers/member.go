@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"curriculum-tracker/middleware"
+	"curriculum-tracker/models"
+	"curriculum-tracker/services"
+	"curriculum-tracker/utils"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *CurriculumHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	members, err := h.curriculumService.GetMembers(userID, curriculumID)
+	if err != nil {
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error getting members: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch members")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, members)
+}
+
+func (h *CurriculumHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	var req models.AddMemberRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.Email == "" || req.Role == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Email and role are required")
+		return
+	}
+
+	member, err := h.curriculumService.AddMember(userID, curriculumID, req)
+	if err != nil {
+		writeMemberError(w, err, "error adding member", "Failed to add member")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, member)
+}
+
+func (h *CurriculumHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	memberID, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req models.UpdateMemberRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	member, err := h.curriculumService.UpdateMember(userID, curriculumID, memberID, req)
+	if err != nil {
+		writeMemberError(w, err, "error updating member", "Failed to update member")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, member)
+}
+
+func (h *CurriculumHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	memberID, err := strconv.Atoi(vars["userId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	err = h.curriculumService.RemoveMember(userID, curriculumID, memberID)
+	if err != nil {
+		writeMemberError(w, err, "error removing member", "Failed to remove member")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Member removed successfully"})
+}
+
+// writeMemberError maps the errors shared by the membership endpoints to
+// responses, logging anything unexpected under logPrefix.
+func writeMemberError(w http.ResponseWriter, err error, logPrefix, message string) {
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+		return
+	}
+	if writeAccessError(w, err) {
+		return
+	}
+	log.Printf("%s: %v", logPrefix, err)
+	utils.WriteError(w, http.StatusInternalServerError, message)
+}
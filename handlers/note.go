@@ -70,6 +70,9 @@ func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
 
 	note, err := h.noteService.CreateNote(userID, projectID, req)
 	if err != nil {
+		if writeAccessError(w, err) {
+			return
+		}
 		log.Printf("error creating note: %v", err)
 		utils.WriteError(w, http.StatusInternalServerError, "Failed to create note")
 		return
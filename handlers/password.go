@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"curriculum-tracker/mailer"
+	"curriculum-tracker/models"
+	"curriculum-tracker/services"
+	"curriculum-tracker/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	claims, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Current password and new password are required")
+		return
+	}
+
+	err := h.authService.ChangePassword(claims.UserID, claims.SessionID, req)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error changing password: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Password changed successfully"})
+}
+
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.ForgotPasswordRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.Email == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	// The response is the same whether or not the account exists, so the
+	// endpoint cannot be used to find out who is registered
+	response := map[string]string{"message": "If an account exists for that email, a reset link has been sent"}
+
+	user, token, err := h.authService.CreatePasswordReset(req.Email, h.config.PasswordResetTTL)
+	if err != nil {
+		if !errors.Is(err, services.ErrUserNotFound) {
+			log.Printf("error creating password reset: %v", err)
+		}
+		utils.WriteJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if err := h.mailer.Send(h.passwordResetMessage(user, token)); err != nil {
+		log.Printf("error sending password reset: %v", err)
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req models.ResetPasswordRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	if req.Token == "" || req.Password == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Token and password are required")
+		return
+	}
+
+	err := h.authService.ResetPassword(req)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid or expired reset token")
+			return
+		}
+		log.Printf("error resetting password: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Password reset successfully"})
+}
+
+func (h *AuthHandler) passwordResetMessage(user *models.User, token string) mailer.Message {
+	link := h.config.PasswordResetURL + "?token=" + url.QueryEscape(token)
+
+	body := fmt.Sprintf(`Hi %s,
+
+Someone asked to reset the password for your Curriculum Tracker account.
+To choose a new password, open this link within %s:
+
+%s
+
+If it was not you, you can ignore this email; your password has not changed.
+`, user.Name, h.config.PasswordResetTTL, link)
+
+	return mailer.Message{
+		From:    h.config.MailFrom,
+		To:      user.Email,
+		Subject: "Reset your Curriculum Tracker password",
+		Body:    body,
+	}
+}
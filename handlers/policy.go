@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"curriculum-tracker/services"
+	"curriculum-tracker/utils"
+	"errors"
+	"net/http"
+)
+
+// writeAccessError writes the response for the access errors services
+// return, and reports whether err was one of them. Resources the caller
+// cannot see respond with 404, so their existence is not leaked; resources
+// the caller can see but not change respond with 403.
+func writeAccessError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrCurriculumNotFound):
+		utils.WriteError(w, http.StatusNotFound, "Curriculum not found")
+	case errors.Is(err, services.ErrProjectNotFound):
+		utils.WriteError(w, http.StatusNotFound, "Project not found")
+	case errors.Is(err, services.ErrMemberNotFound):
+		utils.WriteError(w, http.StatusNotFound, "Member not found")
+	case errors.Is(err, services.ErrNoteNotFound):
+		utils.WriteError(w, http.StatusNotFound, "Note not found")
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.WriteError(w, http.StatusNotFound, "User not found")
+	case errors.Is(err, services.ErrInsufficientRole):
+		utils.WriteError(w, http.StatusForbidden, "Insufficient permissions for this curriculum")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"curriculum-tracker/services"
+	"curriculum-tracker/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteAccessError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantHandled bool
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "curriculum not found", err: services.ErrCurriculumNotFound, wantHandled: true, wantStatus: http.StatusNotFound, wantMessage: "Curriculum not found"},
+		{name: "project not found", err: services.ErrProjectNotFound, wantHandled: true, wantStatus: http.StatusNotFound, wantMessage: "Project not found"},
+		{name: "member not found", err: services.ErrMemberNotFound, wantHandled: true, wantStatus: http.StatusNotFound, wantMessage: "Member not found"},
+		{name: "note not found", err: services.ErrNoteNotFound, wantHandled: true, wantStatus: http.StatusNotFound, wantMessage: "Note not found"},
+		{name: "user not found", err: services.ErrUserNotFound, wantHandled: true, wantStatus: http.StatusNotFound, wantMessage: "User not found"},
+		{name: "insufficient role", err: services.ErrInsufficientRole, wantHandled: true, wantStatus: http.StatusForbidden, wantMessage: "Insufficient permissions for this curriculum"},
+		{name: "wrapped curriculum not found", err: fmt.Errorf("load: %w", services.ErrCurriculumNotFound), wantHandled: true, wantStatus: http.StatusNotFound, wantMessage: "Curriculum not found"},
+		{name: "wrapped insufficient role", err: fmt.Errorf("update: %w", services.ErrInsufficientRole), wantHandled: true, wantStatus: http.StatusForbidden, wantMessage: "Insufficient permissions for this curriculum"},
+		{name: "other error", err: errors.New("connection refused"), wantHandled: false},
+		{name: "validation error", err: &services.ValidationError{Message: "name is required"}, wantHandled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handled := writeAccessError(w, tt.err)
+			if handled != tt.wantHandled {
+				t.Fatalf("writeAccessError() = %v, want %v", handled, tt.wantHandled)
+			}
+
+			if !tt.wantHandled {
+				if w.Body.Len() != 0 {
+					t.Errorf("wrote a response for an unhandled error: %s", w.Body.String())
+				}
+				return
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			var response utils.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Success || response.Error != tt.wantMessage {
+				t.Errorf("response = %+v, want error %q", response, tt.wantMessage)
+			}
+		})
+	}
+}
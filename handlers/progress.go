@@ -5,6 +5,7 @@ import (
 	"curriculum-tracker/models"
 	"curriculum-tracker/services"
 	"curriculum-tracker/utils"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -72,6 +73,16 @@ func (h *ProgressHandler) UpdateProgress(w http.ResponseWriter, r *http.Request)
 
 	progress, err := h.progressService.UpdateProgress(userID, projectID, req)
 	if err != nil {
+		var prereqErr *services.PrerequisitesIncompleteError
+		if errors.As(err, &prereqErr) {
+			utils.WriteErrorWithData(w, http.StatusConflict, "Prerequisites not completed", map[string]interface{}{
+				"blocking_prerequisites": prereqErr.Blocking,
+			})
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
 		log.Printf("error updating progress: %v", err)
 		utils.WriteError(w, http.StatusInternalServerError, "Failed to update progress")
 		return
@@ -101,7 +112,11 @@ func (h *ProgressHandler) GetProgress(w http.ResponseWriter, r *http.Request) {
 
 	progress, err := h.progressService.GetProgressByProjectID(userID, projectID)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "Progress not found")
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error getting progress: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch progress")
 		return
 	}
 
@@ -129,6 +144,9 @@ func (h *ProgressHandler) GetCurriculumProgress(w http.ResponseWriter, r *http.R
 
 	progressList, err := h.progressService.GetProgressByCurriculumID(userID, curriculumID)
 	if err != nil {
+		if writeAccessError(w, err) {
+			return
+		}
 		log.Printf("error getting progress: %v", err)
 		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch progress")
 		return
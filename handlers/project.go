@@ -5,6 +5,7 @@ import (
 	"curriculum-tracker/models"
 	"curriculum-tracker/services"
 	"curriculum-tracker/utils"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -30,7 +31,7 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, ok := middleware.GetUserIDFromContext(r.Context())
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
 		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -54,8 +55,16 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	project, err := h.projectService.CreateProject(curriculumID, req)
+	project, err := h.projectService.CreateProject(userID, curriculumID, req)
 	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
 		log.Printf("error creating project: %v", err)
 		utils.WriteError(w, http.StatusInternalServerError, "Failed to create project")
 		return
@@ -64,6 +73,92 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusCreated, project)
 }
 
+func (h *ProjectHandler) CreateProjectsBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["curriculumId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	var req models.BatchCreateProjectsRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	result, err := h.projectService.CreateProjectsBatch(userID, curriculumID, req)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error creating projects: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to create projects")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, result)
+}
+
+func (h *ProjectHandler) ReorderProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	curriculumID, err := strconv.Atoi(vars["curriculumId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid curriculum ID")
+		return
+	}
+
+	var req models.ReorderProjectsRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	projects, err := h.projectService.ReorderProjects(userID, curriculumID, req)
+	if err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error reordering projects: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to reorder projects")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, projects)
+}
+
 func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -124,7 +219,16 @@ func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 
 	project, err := h.projectService.UpdateProject(userID, projectID, req)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "Project not found")
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			utils.WriteError(w, http.StatusBadRequest, validationErr.Error())
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error updating project: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to update project")
 		return
 	}
 
@@ -152,7 +256,18 @@ func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 
 	err = h.projectService.DeleteProject(userID, projectID)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "Project not found")
+		var dependentsErr *services.DependentProjectsError
+		if errors.As(err, &dependentsErr) {
+			utils.WriteErrorWithData(w, http.StatusConflict, "Other projects depend on this project", map[string]interface{}{
+				"dependents": dependentsErr.Dependents,
+			})
+			return
+		}
+		if writeAccessError(w, err) {
+			return
+		}
+		log.Printf("error deleting project: %v", err)
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to delete project")
 		return
 	}
 
@@ -180,6 +295,9 @@ func (h *ProjectHandler) GetProjectNotes(w http.ResponseWriter, r *http.Request)
 
 	notes, err := h.noteService.GetNotesByProjectID(userID, projectID)
 	if err != nil {
+		if writeAccessError(w, err) {
+			return
+		}
 		log.Printf("error getting notes: %v", err)
 		utils.WriteError(w, http.StatusInternalServerError, "Failed to fetch notes")
 		return
@@ -0,0 +1,109 @@
+// Package testdb gives integration tests a migrated Postgres database.
+package testdb
+
+import (
+	"context"
+	"curriculum-tracker/database"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// migrationLockID is the advisory lock that keeps test packages, which go
+// test runs in parallel, from migrating the same database at once.
+const migrationLockID = 741852963
+
+var emailCounter atomic.Int64
+
+// Open connects to the database in TEST_DATABASE_URL and runs the
+// migrations, or skips the test when the variable is unset. It is never
+// DATABASE_URL, so tests cannot write to a real database by accident. Tests
+// create their own users and curricula rather than truncating tables, so a
+// development database can be shared.
+func Open(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := database.Connect(url)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		t.Fatalf("failed to lock migrations: %v", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// OpenSchema connects to TEST_DATABASE_URL with a new, empty schema first on
+// the search path, and drops the schema when the test ends. Migrations are
+// not run, so tests can set up the tables of an older version first. It
+// skips the test when the variable is unset.
+func OpenSchema(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := database.Connect(url)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d_%d", time.Now().UnixNano(), emailCounter.Add(1))
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+
+	// lib/pq passes unknown connection parameters on as run-time settings
+	separator := " "
+	if strings.Contains(url, "://") {
+		separator = "?"
+		if strings.Contains(url, "?") {
+			separator = "&"
+		}
+	}
+	db, err := database.Connect(url + separator + "search_path=" + schema)
+	if err != nil {
+		t.Fatalf("failed to connect to test schema: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Close()
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("failed to drop schema: %v", err)
+		}
+	})
+
+	return db
+}
+
+// Email returns an address no other test run has used.
+func Email() string {
+	return fmt.Sprintf("test-%d-%d@example.com", time.Now().UnixNano(), emailCounter.Add(1))
+}
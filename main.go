@@ -3,9 +3,13 @@ package main
 import (
 	"curriculum-tracker/config"
 	"curriculum-tracker/database"
+	"curriculum-tracker/mailer"
 	"curriculum-tracker/routes"
+	"curriculum-tracker/services"
+	"database/sql"
 	"log"
 	"net/http"
+	"time"
 )
 
 func main() {
@@ -21,8 +25,36 @@ func main() {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
-	router := routes.Setup(db, cfg)
+	// Without a mail directory, emails are only written to the log
+	var mail mailer.Mailer = mailer.LogMailer{}
+	if cfg.MailDir != "" {
+		fileMailer, err := mailer.NewFileMailer(cfg.MailDir)
+		if err != nil {
+			log.Fatal("Failed to set up mailer:", err)
+		}
+		mail = fileMailer
+	}
+
+	router := routes.Setup(db, cfg, mail)
+
+	go purgeDeletedAccounts(db, cfg.AccountDeletionGrace)
 
 	log.Printf("Server starting on port %s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, router))
 }
+
+// purgeDeletedAccounts deletes accounts whose deletion grace period has run
+// out, at startup and then every hour.
+func purgeDeletedAccounts(db *sql.DB, grace time.Duration) {
+	authService := services.NewAuthService(db)
+	for {
+		purged, err := authService.PurgeDeletedAccounts(grace)
+		if err != nil {
+			log.Printf("error purging deleted accounts: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("purged %d deleted accounts", purged)
+		}
+		time.Sleep(time.Hour)
+	}
+}
@@ -2,16 +2,38 @@ package middleware
 
 import (
 	"context"
+	"curriculum-tracker/models"
 	"curriculum-tracker/utils"
+	"log"
 	"net/http"
 	"strings"
 )
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey   contextKey = "userID"
+	UserRoleKey contextKey = "userRole"
+	ClaimsKey   contextKey = "claims"
+	ScopesKey   contextKey = "scopes"
+)
+
+// TokenStore reports whether an access token has been revoked, on its own
+// or through its session.
+type TokenStore interface {
+	IsRevoked(tokenID string, sessionID int) (bool, error)
+}
 
-func Auth(jwtSecret string) func(http.Handler) http.Handler {
+// AccessTokenStore looks up personal access tokens, returning nil for ones
+// that are unknown or expired.
+type AccessTokenStore interface {
+	AuthenticateAccessToken(token string) (*models.AccessTokenOwner, error)
+}
+
+// Auth accepts either a JWT access token or a personal access token, and
+// puts the user, their role and the token's scopes in the context. Personal
+// access tokens carry no claims.
+func Auth(jwtSecret string, tokens TokenStore, accessTokens AccessTokenStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Let OPTIONS requests through
@@ -32,13 +54,53 @@ func Auth(jwtSecret string) func(http.Handler) http.Handler {
 				return
 			}
 
+			if strings.HasPrefix(bearerToken[1], utils.AccessTokenPrefix) {
+				owner, err := accessTokens.AuthenticateAccessToken(bearerToken[1])
+				if err != nil {
+					log.Printf("error checking access token: %v", err)
+					utils.WriteError(w, http.StatusInternalServerError, "Failed to verify token")
+					return
+				}
+				if owner == nil {
+					utils.WriteError(w, http.StatusUnauthorized, "Invalid token")
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserIDKey, owner.UserID)
+				ctx = context.WithValue(ctx, UserRoleKey, owner.Role)
+				ctx = context.WithValue(ctx, ScopesKey, []string(owner.Scopes))
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			claims, err := utils.ValidateToken(bearerToken[1], jwtSecret)
 			if err != nil {
 				utils.WriteError(w, http.StatusUnauthorized, "Invalid token")
 				return
 			}
 
+			// Tokens issued before sessions or scopes existed are no longer
+			// accepted; clients refresh them on 401
+			if claims.ID == "" || claims.SessionID == 0 || claims.Scope == "" {
+				utils.WriteError(w, http.StatusUnauthorized, "Invalid token")
+				return
+			}
+
+			revoked, err := tokens.IsRevoked(claims.ID, claims.SessionID)
+			if err != nil {
+				log.Printf("error checking token revocation: %v", err)
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to verify token")
+				return
+			}
+			if revoked {
+				utils.WriteError(w, http.StatusUnauthorized, "Token has been revoked")
+				return
+			}
+
 			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+			ctx = context.WithValue(ctx, ClaimsKey, claims)
+			ctx = context.WithValue(ctx, ScopesKey, claims.Scopes())
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -48,3 +110,13 @@ func GetUserIDFromContext(ctx context.Context) (int, bool) {
 	userID, ok := ctx.Value(UserIDKey).(int)
 	return userID, ok
 }
+
+func GetUserRoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(UserRoleKey).(string)
+	return role, ok
+}
+
+func GetClaimsFromContext(ctx context.Context) (*utils.Claims, bool) {
+	claims, ok := ctx.Value(ClaimsKey).(*utils.Claims)
+	return claims, ok
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"curriculum-tracker/utils"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitStore keeps the token buckets behind RateLimit. Take spends one
+// request from the bucket for key, creating a full one if there is none,
+// and returns the tokens left and whether the request was allowed.
+type RateLimitStore interface {
+	Take(key string, limit utils.RateLimit) (float64, bool, error)
+}
+
+// RateLimit limits requests to a route group with a token bucket per
+// client: per user on authenticated routes, so it must run after Auth, and
+// otherwise per IP, as ClientIP finds it behind trustedProxies proxies.
+// Limits are reported in RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers. If the store fails the request is let through
+// rather than taking the API down with it.
+func RateLimit(store RateLimitStore, group string, limit utils.RateLimit, trustedProxies int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := group + ":ip:" + utils.ClientIP(r, trustedProxies)
+			if userID, ok := GetUserIDFromContext(r.Context()); ok {
+				key = fmt.Sprintf("%s:user:%d", group, userID)
+			}
+
+			tokens, allowed, err := store.Take(key, limit)
+			if err != nil {
+				log.Printf("error checking rate limit: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(tokens)))
+			w.Header().Set("RateLimit-Reset", ceilSeconds(limit.Until(tokens, float64(limit.Burst))))
+
+			if !allowed {
+				w.Header().Set("Retry-After", ceilSeconds(limit.Until(tokens, 1)))
+				utils.WriteError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// MemoryRateLimitStore keeps token buckets in process memory. It is the
+// simplest store, but every instance of the API counts separately.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time
+}
+
+func NewMemoryRateLimitStore(now func() time.Time) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+		now:     now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, limit utils.RateLimit) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	// A full bucket is the same as no bucket, so drop them now and then
+	if now.Sub(s.lastSweep) >= time.Minute {
+		for k, b := range s.buckets {
+			if !now.Before(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	tokens, allowed := limit.Take(b.tokens, now.Sub(b.updatedAt))
+	b.tokens = tokens
+	b.updatedAt = now
+	b.fullAt = now.Add(limit.Until(tokens, float64(limit.Burst)))
+
+	return tokens, allowed, nil
+}
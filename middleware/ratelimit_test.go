@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"context"
+	"curriculum-tracker/utils"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock tests move by hand.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+// failingStore fails every Take.
+type failingStore struct{}
+
+func (failingStore) Take(key string, limit utils.RateLimit) (float64, bool, error) {
+	return 0, false, errors.New("store unavailable")
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	clock := newFakeClock()
+	store := NewMemoryRateLimitStore(clock.Now)
+	limit := utils.RateLimit{Burst: 3, Period: 3 * time.Second}
+
+	take := func(key string, wantTokens float64, wantAllowed bool) {
+		t.Helper()
+		tokens, allowed, err := store.Take(key, limit)
+		if err != nil {
+			t.Fatalf("Take failed: %v", err)
+		}
+		if tokens != wantTokens || allowed != wantAllowed {
+			t.Errorf("Take(%s) = %v, %v, want %v, %v", key, tokens, allowed, wantTokens, wantAllowed)
+		}
+	}
+
+	// A new key starts with a full bucket
+	take("a", 2, true)
+	take("a", 1, true)
+	take("a", 0, true)
+	take("a", 0, false)
+
+	// Other keys have their own bucket
+	take("b", 2, true)
+
+	// One token a second comes back
+	clock.Advance(500 * time.Millisecond)
+	take("a", 0.5, false)
+	clock.Advance(500 * time.Millisecond)
+	take("a", 0, true)
+
+	// But never more than the burst
+	clock.Advance(time.Hour)
+	take("a", 2, true)
+}
+
+func TestMemoryRateLimitStoreSweepsFullBuckets(t *testing.T) {
+	clock := newFakeClock()
+	store := NewMemoryRateLimitStore(clock.Now)
+	limit := utils.RateLimit{Burst: 10, Period: 10 * time.Minute}
+
+	// Each bucket takes a minute to refill after one request
+	store.Take("refilled", limit)
+	clock.Advance(30 * time.Second)
+	store.Take("draining", limit)
+
+	// The next sweep is due a minute after the first request, when only
+	// "refilled" is full again
+	clock.Advance(40 * time.Second)
+	store.Take("trigger", limit)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, ok := store.buckets["refilled"]; ok {
+		t.Error("full bucket was not swept")
+	}
+	if _, ok := store.buckets["draining"]; !ok {
+		t.Error("bucket that is not full yet was swept")
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	clock := newFakeClock()
+	limit := utils.RateLimit{Burst: 3, Period: 3 * time.Second}
+	handler := RateLimit(NewMemoryRateLimitStore(clock.Now), "test", limit, 0)(okHandler)
+
+	tests := []struct {
+		advance    time.Duration
+		wantStatus int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{wantStatus: http.StatusOK, remaining: "2", reset: "1"},
+		{wantStatus: http.StatusOK, remaining: "1", reset: "2"},
+		{wantStatus: http.StatusOK, remaining: "0", reset: "3"},
+		{wantStatus: http.StatusTooManyRequests, remaining: "0", reset: "3", retryAfter: "1"},
+		// Half a token rounds the waits up to whole seconds
+		{advance: 500 * time.Millisecond, wantStatus: http.StatusTooManyRequests, remaining: "0", reset: "3", retryAfter: "1"},
+		{advance: 500 * time.Millisecond, wantStatus: http.StatusOK, remaining: "0", reset: "3"},
+		{advance: 2 * time.Second, wantStatus: http.StatusOK, remaining: "1", reset: "2"},
+	}
+
+	for i, tt := range tests {
+		clock.Advance(tt.advance)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("request %d: status = %d, want %d", i+1, w.Code, tt.wantStatus)
+		}
+		headers := map[string]string{
+			"RateLimit-Limit":     "3",
+			"RateLimit-Remaining": tt.remaining,
+			"RateLimit-Reset":     tt.reset,
+			"Retry-After":         tt.retryAfter,
+		}
+		for name, want := range headers {
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("request %d: %s = %q, want %q", i+1, name, got, want)
+			}
+		}
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	limit := utils.RateLimit{Burst: 1, Period: time.Minute}
+
+	tests := []struct {
+		name           string
+		trustedProxies int
+		first, second  func(r *http.Request) *http.Request
+		wantSecond     int
+	}{
+		{
+			name:       "same IP",
+			first:      remoteAddr("203.0.113.7:1000"),
+			second:     remoteAddr("203.0.113.7:2000"),
+			wantSecond: http.StatusTooManyRequests,
+		},
+		{
+			name:       "different IPs",
+			first:      remoteAddr("203.0.113.7:1000"),
+			second:     remoteAddr("203.0.113.8:1000"),
+			wantSecond: http.StatusOK,
+		},
+		{
+			name:       "forwarded header without trusted proxies",
+			first:      forwardedFor("203.0.113.7:1000", "198.51.100.1"),
+			second:     forwardedFor("203.0.113.7:1000", "198.51.100.2"),
+			wantSecond: http.StatusTooManyRequests,
+		},
+		{
+			name:           "client-chosen forwarded entries",
+			trustedProxies: 1,
+			first:          forwardedFor("10.0.0.2:1000", "1.1.1.1, 198.51.100.1"),
+			second:         forwardedFor("10.0.0.2:1000", "2.2.2.2, 198.51.100.1"),
+			wantSecond:     http.StatusTooManyRequests,
+		},
+		{
+			name:           "clients behind the same proxy",
+			trustedProxies: 1,
+			first:          forwardedFor("10.0.0.2:1000", "198.51.100.1"),
+			second:         forwardedFor("10.0.0.2:1000", "198.51.100.2"),
+			wantSecond:     http.StatusOK,
+		},
+		{
+			name:       "same user from different IPs",
+			first:      asUser(1, "203.0.113.7:1000"),
+			second:     asUser(1, "203.0.113.8:1000"),
+			wantSecond: http.StatusTooManyRequests,
+		},
+		{
+			name:       "different users from the same IP",
+			first:      asUser(1, "203.0.113.7:1000"),
+			second:     asUser(2, "203.0.113.7:1000"),
+			wantSecond: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			handler := RateLimit(NewMemoryRateLimitStore(clock.Now), "test", limit, tt.trustedProxies)(okHandler)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.first(httptest.NewRequest(http.MethodGet, "/", nil)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("first request: status = %d, want %d", w.Code, http.StatusOK)
+			}
+
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, tt.second(httptest.NewRequest(http.MethodGet, "/", nil)))
+			if w.Code != tt.wantSecond {
+				t.Errorf("second request: status = %d, want %d", w.Code, tt.wantSecond)
+			}
+		})
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	handler := RateLimit(failingStore{}, "test", utils.RateLimit{}, 0)(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "" {
+		t.Errorf("RateLimit-Limit = %q, want none", got)
+	}
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	limit := utils.RateLimit{Burst: 1, Period: time.Minute}
+	handler := RateLimit(failingStore{}, "test", limit, 0)(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func remoteAddr(addr string) func(r *http.Request) *http.Request {
+	return func(r *http.Request) *http.Request {
+		r.RemoteAddr = addr
+		return r
+	}
+}
+
+func forwardedFor(addr, forwarded string) func(r *http.Request) *http.Request {
+	return func(r *http.Request) *http.Request {
+		r.RemoteAddr = addr
+		r.Header.Set("X-Forwarded-For", forwarded)
+		return r
+	}
+}
+
+func asUser(userID int, addr string) func(r *http.Request) *http.Request {
+	return func(r *http.Request) *http.Request {
+		r.RemoteAddr = addr
+		return r.WithContext(context.WithValue(r.Context(), UserIDKey, userID))
+	}
+}
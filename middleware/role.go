@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"curriculum-tracker/utils"
+	"net/http"
+)
+
+// RequireRole only lets through requests from users holding one of roles.
+// It must run after Auth; everyone else gets 403.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !HasRole(r.Context(), roles...) {
+				utils.WriteError(w, http.StatusForbidden, "Insufficient permissions")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasRole reports whether the authenticated user holds one of roles.
+func HasRole(ctx context.Context, roles ...string) bool {
+	role, ok := GetUserRoleFromContext(ctx)
+	if !ok {
+		return false
+	}
+	for _, allowed := range roles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
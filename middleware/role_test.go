@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"curriculum-tracker/models"
+	"curriculum-tracker/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "test-secret"
+
+// fakeTokenStore revokes nothing.
+type fakeTokenStore struct{}
+
+func (fakeTokenStore) IsRevoked(tokenID string, sessionID int) (bool, error) {
+	return false, nil
+}
+
+// fakeAccessTokenStore maps personal access tokens to their owners.
+type fakeAccessTokenStore map[string]*models.AccessTokenOwner
+
+func (s fakeAccessTokenStore) AuthenticateAccessToken(token string) (*models.AccessTokenOwner, error) {
+	return s[token], nil
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		name  string
+		role  *string
+		roles []string
+		want  bool
+	}{
+		{name: "admin allowed", role: ptr(models.UserRoleAdmin), roles: []string{models.UserRoleAdmin}, want: true},
+		{name: "one of several", role: ptr(models.UserRoleMentor), roles: []string{models.UserRoleAdmin, models.UserRoleMentor}, want: true},
+		{name: "learner not admin", role: ptr(models.UserRoleLearner), roles: []string{models.UserRoleAdmin}, want: false},
+		{name: "mentor not admin", role: ptr(models.UserRoleMentor), roles: []string{models.UserRoleAdmin}, want: false},
+		{name: "no roles allowed", role: ptr(models.UserRoleAdmin), roles: nil, want: false},
+		{name: "empty role", role: ptr(""), roles: []string{models.UserRoleAdmin}, want: false},
+		{name: "unauthenticated", role: nil, roles: []string{models.UserRoleAdmin}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.role != nil {
+				ctx = context.WithValue(ctx, UserRoleKey, *tt.role)
+			}
+			if got := HasRole(ctx, tt.roles...); got != tt.want {
+				t.Errorf("HasRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		role   *string
+		want   int
+	}{
+		{name: "admin", method: http.MethodGet, role: ptr(models.UserRoleAdmin), want: http.StatusOK},
+		{name: "mentor", method: http.MethodGet, role: ptr(models.UserRoleMentor), want: http.StatusOK},
+		{name: "learner", method: http.MethodGet, role: ptr(models.UserRoleLearner), want: http.StatusForbidden},
+		{name: "unauthenticated", method: http.MethodGet, role: nil, want: http.StatusForbidden},
+		{name: "preflight", method: http.MethodOptions, role: nil, want: http.StatusOK},
+	}
+
+	handler := RequireRole(models.UserRoleAdmin, models.UserRoleMentor)(okHandler)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.role != nil {
+				r = r.WithContext(context.WithValue(r.Context(), UserRoleKey, *tt.role))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// TestAdminChain runs requests through the middleware the /admin subrouter
+// uses: Auth, then the admin role, then the admin scope.
+func TestAdminChain(t *testing.T) {
+	accessTokens := fakeAccessTokenStore{
+		"ctp_admin_all":   {TokenID: 1, UserID: 1, Role: models.UserRoleAdmin, Scopes: models.AllScopes},
+		"ctp_admin_read":  {TokenID: 2, UserID: 1, Role: models.UserRoleAdmin, Scopes: models.ReadScopes},
+		"ctp_learner_all": {TokenID: 3, UserID: 2, Role: models.UserRoleLearner, Scopes: models.AllScopes},
+	}
+
+	handler := Auth(testJWTSecret, fakeTokenStore{}, accessTokens)(
+		RequireRole(models.UserRoleAdmin)(
+			RequireScope(models.ScopeAdmin)(okHandler),
+		),
+	)
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{name: "learner session", token: sessionToken(t, models.UserRoleLearner), want: http.StatusForbidden},
+		{name: "mentor session", token: sessionToken(t, models.UserRoleMentor), want: http.StatusForbidden},
+		{name: "admin session", token: sessionToken(t, models.UserRoleAdmin), want: http.StatusOK},
+		{name: "admin token with admin scope", token: "ctp_admin_all", want: http.StatusOK},
+		{name: "admin token without admin scope", token: "ctp_admin_read", want: http.StatusForbidden},
+		{name: "learner token with admin scope", token: "ctp_learner_all", want: http.StatusForbidden},
+		{name: "unknown token", token: "ctp_unknown", want: http.StatusUnauthorized},
+		{name: "no token", token: "", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// sessionToken issues an access token like signing in does.
+func sessionToken(t *testing.T, role string) string {
+	t.Helper()
+	token, err := utils.GenerateToken(1, "user@example.com", role, models.AllScopes, 1, testJWTSecret, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	return token
+}
+
+func ptr(s string) *string {
+	return &s
+}
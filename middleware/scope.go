@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"curriculum-tracker/utils"
+	"fmt"
+	"net/http"
+)
+
+// RequireScope only lets through requests whose token carries scope. It
+// must run after Auth; everyone else gets 403 insufficient_scope, naming the
+// scope that was missing.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !HasScope(r.Context(), scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+				utils.WriteErrorWithData(w, http.StatusForbidden, "insufficient_scope", map[string]string{"required_scope": scope})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasScope reports whether the request's token carries scope.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, _ := ctx.Value(ScopesKey).([]string)
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
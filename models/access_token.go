@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// AccessToken is a personal access token as shown to its owner. The token
+// itself is only returned once, when it is created; Prefix is enough to
+// recognise it afterwards.
+type AccessToken struct {
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Scopes     StringArray `json:"scopes"`
+	ExpiresAt  *time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// CreateAccessTokenRequest names the token's scopes in Scopes. Scope is a
+// shorthand kept for older clients: "read" for every read scope, "write"
+// for every scope.
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	Scope         string   `json:"scope"`
+	ExpiresInDays *int     `json:"expires_in_days"`
+}
+
+type CreateAccessTokenResponse struct {
+	AccessToken
+	Token string `json:"token"`
+}
+
+// AccessTokenOwner is who a personal access token authenticates as, and
+// what it lets them do.
+type AccessTokenOwner struct {
+	TokenID int
+	UserID  int
+	Role    string
+	Scopes  StringArray
+}
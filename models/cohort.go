@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+type CohortReport struct {
+	CurriculumID  int              `json:"curriculum_id"`
+	StalledDays   int              `json:"stalled_days"`
+	TotalProjects int              `json:"total_projects"`
+	Learners      []LearnerSummary `json:"learners"`
+}
+
+type LearnerSummary struct {
+	UserID            int                     `json:"user_id"`
+	Email             string                  `json:"email"`
+	Name              string                  `json:"name"`
+	CompletedProjects int                     `json:"completed_projects"`
+	ByProjectType     []ProjectTypeCompletion `json:"by_project_type"`
+	InProgress        []CohortProject         `json:"in_progress"`
+	Stalled           []CohortProject         `json:"stalled"`
+	MinutesLast7Days  int                     `json:"minutes_last_7_days"`
+	MinutesLast30Days int                     `json:"minutes_last_30_days"`
+}
+
+type ProjectTypeCompletion struct {
+	ProjectType       string `json:"project_type"`
+	Projects          int    `json:"projects"`
+	CompletedProjects int    `json:"completed_projects"`
+}
+
+type CohortProject struct {
+	ProjectID            int        `json:"project_id"`
+	Identifier           string     `json:"identifier"`
+	Name                 string     `json:"name"`
+	CompletionPercentage int        `json:"completion_percentage"`
+	StartedAt            *time.Time `json:"started_at"`
+	LastTimeEntry        *time.Time `json:"last_time_entry"`
+}
@@ -9,6 +9,8 @@ type Curriculum struct {
 	UserID      int       `json:"user_id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	IsTemplate  bool      `json:"is_template"`
+	Role        string    `json:"role"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	Projects    []Project `json:"projects"`
@@ -23,11 +25,29 @@ func NewCurriculum() *Curriculum {
 type CreateCurriculumRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	IsTemplate  bool   `json:"is_template"`
 }
 
 type UpdateCurriculumRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	IsTemplate  *bool  `json:"is_template"`
+}
+
+type CloneCurriculumRequest struct {
+	Name        string `json:"name"`
+	TargetEmail string `json:"target_email"`
+}
+
+type CurriculumTemplate struct {
+	ID            int       `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	OwnerID       int       `json:"owner_id"`
+	OwnerName     string    `json:"owner_name"`
+	TotalProjects int       `json:"total_projects"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 type CurriculumWithStats struct {
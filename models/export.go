@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+const CurriculumDocumentVersion = 1
+
+type CurriculumDocument struct {
+	Version    int                `json:"version" yaml:"version"`
+	ExportedAt time.Time          `json:"exported_at" yaml:"exported_at"`
+	Curriculum DocumentCurriculum `json:"curriculum" yaml:"curriculum"`
+	Projects   []DocumentProject  `json:"projects" yaml:"projects"`
+}
+
+type DocumentCurriculum struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+}
+
+type DocumentProject struct {
+	Identifier         string              `json:"identifier" yaml:"identifier"`
+	Name               string              `json:"name" yaml:"name"`
+	Description        string              `json:"description" yaml:"description"`
+	LearningObjectives []string            `json:"learning_objectives" yaml:"learning_objectives"`
+	EstimatedTime      string              `json:"estimated_time,omitempty" yaml:"estimated_time,omitempty"`
+	Prerequisites      []string            `json:"prerequisites" yaml:"prerequisites"`
+	ProjectType        string              `json:"project_type" yaml:"project_type"`
+	BranchGroup        *int                `json:"branch_group,omitempty" yaml:"branch_group,omitempty"`
+	PositionOrder      int                 `json:"position_order" yaml:"position_order"`
+	Progress           *DocumentProgress   `json:"progress,omitempty" yaml:"progress,omitempty"`
+	Notes              []DocumentNote      `json:"notes,omitempty" yaml:"notes,omitempty"`
+	TimeEntries        []DocumentTimeEntry `json:"time_entries,omitempty" yaml:"time_entries,omitempty"`
+}
+
+type DocumentProgress struct {
+	Status               string     `json:"status" yaml:"status"`
+	CompletionPercentage int        `json:"completion_percentage" yaml:"completion_percentage"`
+	StartedAt            *time.Time `json:"started_at,omitempty" yaml:"started_at,omitempty"`
+	CompletedAt          *time.Time `json:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+}
+
+type DocumentNote struct {
+	Title     string    `json:"title" yaml:"title"`
+	Content   string    `json:"content" yaml:"content"`
+	NoteType  string    `json:"note_type" yaml:"note_type"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+}
+
+type DocumentTimeEntry struct {
+	Minutes     int    `json:"minutes" yaml:"minutes"`
+	Description string `json:"description" yaml:"description"`
+	Date        string `json:"date" yaml:"date"`
+}
+
+type ExportOptions struct {
+	IncludeProgress    bool
+	IncludeNotes       bool
+	IncludeTimeEntries bool
+}
+
+type ImportResult struct {
+	Curriculum    *Curriculum       `json:"curriculum"`
+	IdentifierMap map[string]string `json:"identifier_map"`
+}
@@ -0,0 +1,45 @@
+package models
+
+type GraphNode struct {
+	ProjectID     int    `json:"project_id"`
+	Identifier    string `json:"identifier"`
+	Name          string `json:"name"`
+	ProjectType   string `json:"project_type"`
+	BranchGroup   *int   `json:"branch_group,omitempty"`
+	PositionOrder int    `json:"position_order"`
+	Status        string `json:"status"`
+}
+
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type GraphGroup struct {
+	Reference   string   `json:"reference"`
+	ProjectType string   `json:"project_type"`
+	BranchGroup int      `json:"branch_group"`
+	Members     []string `json:"members"`
+}
+
+type DanglingReference struct {
+	Identifier   string `json:"identifier"`
+	Prerequisite string `json:"prerequisite"`
+}
+
+type CurriculumGraph struct {
+	CurriculumID       int                 `json:"curriculum_id"`
+	Nodes              []GraphNode         `json:"nodes"`
+	Edges              []GraphEdge         `json:"edges"`
+	Groups             []GraphGroup        `json:"groups"`
+	Layers             [][]string          `json:"layers"`
+	Cycles             [][]string          `json:"cycles"`
+	DanglingReferences []DanglingReference `json:"dangling_references"`
+	Unreachable        []string            `json:"unreachable"`
+}
+
+type NextProject struct {
+	Project         Project `json:"project"`
+	Unlocks         int     `json:"unlocks"`
+	DownstreamCount int     `json:"downstream_count"`
+}
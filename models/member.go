@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+type CurriculumMember struct {
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AddMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+const (
+	MemberRoleOwner   = "owner"
+	MemberRoleEditor  = "editor"
+	MemberRoleLearner = "learner"
+)
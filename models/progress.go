@@ -20,6 +20,14 @@ type Progress struct {
 type UpdateProgressRequest struct {
 	Status               string `json:"status"`
 	CompletionPercentage int    `json:"completion_percentage"`
+	Force                bool   `json:"force"`
+}
+
+type BlockingPrerequisite struct {
+	ProjectID  int    `json:"project_id"`
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
 }
 
 const (
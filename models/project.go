@@ -33,6 +33,16 @@ func (a *StringArray) Scan(value interface{}) error {
 	return nil
 }
 
+type Estimate struct {
+	Text       string `json:"text"`
+	MinMinutes int    `json:"min_minutes"`
+	MaxMinutes int    `json:"max_minutes"`
+}
+
+func (e Estimate) ExpectedMinutes() float64 {
+	return float64(e.MinMinutes+e.MaxMinutes) / 2
+}
+
 type Project struct {
 	ID                 int         `json:"id"`
 	CurriculumID       int         `json:"curriculum_id"`
@@ -41,8 +51,10 @@ type Project struct {
 	Description        string      `json:"description"`
 	LearningObjectives StringArray `json:"learning_objectives"`
 	EstimatedTime      string      `json:"estimated_time"`
+	Estimate           *Estimate   `json:"estimate"`
 	Prerequisites      StringArray `json:"prerequisites"`
 	ProjectType        string      `json:"project_type"`
+	BranchGroup        *int        `json:"branch_group"`
 	PositionOrder      int         `json:"position_order"`
 	CreatedAt          time.Time   `json:"created_at"`
 	UpdatedAt          time.Time   `json:"updated_at"`
@@ -56,6 +68,7 @@ type CreateProjectRequest struct {
 	EstimatedTime      string      `json:"estimated_time"`
 	Prerequisites      StringArray `json:"prerequisites"`
 	ProjectType        string      `json:"project_type"`
+	BranchGroup        *int        `json:"branch_group"`
 	PositionOrder      int         `json:"position_order"`
 }
 
@@ -69,6 +82,30 @@ type UpdateProjectRequest struct {
 	PositionOrder      int         `json:"position_order"`
 }
 
+type ReorderProjectsRequest struct {
+	ProjectIDs []int `json:"project_ids"`
+}
+
+type DependentProject struct {
+	ProjectID  int    `json:"project_id"`
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+}
+
+type BatchProjectItem struct {
+	Key string `json:"key"`
+	CreateProjectRequest
+}
+
+type BatchCreateProjectsRequest struct {
+	Projects []BatchProjectItem `json:"projects"`
+}
+
+type BatchCreateProjectsResult struct {
+	Projects    []Project         `json:"projects"`
+	Identifiers map[string]string `json:"identifiers"`
+}
+
 const (
 	ProjectTypeRoot            = "root"
 	ProjectTypeRootTest        = "rootTest"
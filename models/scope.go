@@ -0,0 +1,39 @@
+package models
+
+// Scopes limit what a token can be used for. Access tokens from a session
+// carry every scope; personal access tokens carry the ones they were
+// created with. Scopes never grant more than the user's roles allow.
+const (
+	ScopeCurriculaRead  = "curricula:read"
+	ScopeCurriculaWrite = "curricula:write"
+	ScopeProgressRead   = "progress:read"
+	ScopeProgressWrite  = "progress:write"
+	ScopeNotesRead      = "notes:read"
+	ScopeNotesWrite     = "notes:write"
+	ScopeTimeRead       = "time:read"
+	ScopeTimeWrite      = "time:write"
+	ScopeAnalyticsRead  = "analytics:read"
+	ScopeAdmin          = "admin"
+)
+
+var AllScopes = []string{
+	ScopeCurriculaRead,
+	ScopeCurriculaWrite,
+	ScopeProgressRead,
+	ScopeProgressWrite,
+	ScopeNotesRead,
+	ScopeNotesWrite,
+	ScopeTimeRead,
+	ScopeTimeWrite,
+	ScopeAnalyticsRead,
+	ScopeAdmin,
+}
+
+// ReadScopes are the scopes that cannot change anything.
+var ReadScopes = []string{
+	ScopeCurriculaRead,
+	ScopeProgressRead,
+	ScopeNotesRead,
+	ScopeTimeRead,
+	ScopeAnalyticsRead,
+}
@@ -27,3 +27,54 @@ type TimeStats struct {
 	ProjectBreakdown map[string]int `json:"project_breakdown"`
 	WeeklyAverage    float64        `json:"weekly_average"`
 }
+
+type CurriculumForecast struct {
+	CurriculumID            int        `json:"curriculum_id"`
+	RemainingProjects       int        `json:"remaining_projects"`
+	UnestimatedProjects     []string   `json:"unestimated_projects"`
+	RemainingHoursMin       float64    `json:"remaining_hours_min"`
+	RemainingHoursMax       float64    `json:"remaining_hours_max"`
+	RemainingHours          float64    `json:"remaining_hours"`
+	CriticalPath            []string   `json:"critical_path"`
+	CriticalPathHours       float64    `json:"critical_path_hours"`
+	TrailingWeeklyHours     float64    `json:"trailing_weekly_hours"`
+	ProjectedCompletionDate *time.Time `json:"projected_completion_date"`
+}
+
+type ProjectVariance struct {
+	ProjectID       int       `json:"project_id"`
+	Identifier      string    `json:"identifier"`
+	Name            string    `json:"name"`
+	ProjectType     string    `json:"project_type"`
+	Status          string    `json:"status"`
+	Estimate        *Estimate `json:"estimate"`
+	ActualMinutes   int       `json:"actual_minutes"`
+	VarianceMinutes *float64  `json:"variance_minutes"`
+	VarianceRatio   *float64  `json:"variance_ratio"`
+	Outcome         string    `json:"outcome"`
+}
+
+type ProjectTypeVariance struct {
+	ProjectType       string   `json:"project_type"`
+	Projects          int      `json:"projects"`
+	CompletedProjects int      `json:"completed_projects"`
+	EstimatedMinutes  float64  `json:"estimated_minutes"`
+	ActualMinutes     int      `json:"actual_minutes"`
+	CalibrationFactor *float64 `json:"calibration_factor"`
+}
+
+type VarianceReport struct {
+	CurriculumID          int                   `json:"curriculum_id"`
+	Projects              []ProjectVariance     `json:"projects"`
+	ByProjectType         []ProjectTypeVariance `json:"by_project_type"`
+	CalibrationFactor     *float64              `json:"calibration_factor"`
+	CalibrationSampleSize int                   `json:"calibration_sample_size"`
+}
+
+const (
+	VarianceOver        = "over"
+	VarianceUnder       = "under"
+	VarianceWithin      = "within"
+	VariancePending     = "pending"
+	VarianceUnestimated = "unestimated"
+)
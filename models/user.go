@@ -5,12 +5,14 @@ import (
 )
 
 type User struct {
-	ID           int       `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Name         string    `json:"name"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID            int       `json:"id"`
+	Email         string    `json:"email"`
+	PasswordHash  string    `json:"-"`
+	Name          string    `json:"name"`
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 type CreateUserRequest struct {
@@ -25,6 +27,60 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
+	Token          string    `json:"token"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+	RefreshToken   string    `json:"refresh_token"`
+	User           User      `json:"user"`
+}
+
+// PendingVerificationResponse is returned by registration when users must
+// verify their email before they can log in.
+type PendingVerificationResponse struct {
+	Message string `json:"message"`
+	User    User   `json:"user"`
+}
+
+// UpdateProfileRequest changes the fields that are set. Changing the email
+// needs the current password and the new address has to be verified again.
+type UpdateProfileRequest struct {
+	Name            *string `json:"name"`
+	Email           *string `json:"email"`
+	CurrentPassword string  `json:"current_password"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type DeleteAccountResponse struct {
+	Message     string    `json:"message"`
+	DeletionDue time.Time `json:"deletion_due"`
+}
+
+type VerifyEmailRequest struct {
 	Token string `json:"token"`
-	User  User   `json:"user"`
 }
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+const (
+	UserRoleAdmin   = "admin"
+	UserRoleMentor  = "mentor"
+	UserRoleLearner = "learner"
+)
@@ -3,28 +3,47 @@ package routes
 import (
 	"curriculum-tracker/config"
 	"curriculum-tracker/handlers"
+	"curriculum-tracker/mailer"
 	"curriculum-tracker/middleware"
+	"curriculum-tracker/models"
 	"curriculum-tracker/services"
 	"database/sql"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
-func Setup(db *sql.DB, cfg *config.Config) *mux.Router {
+func Setup(db *sql.DB, cfg *config.Config, mail mailer.Mailer) *mux.Router {
 	authService := services.NewAuthService(db)
+	sessionService := services.NewSessionService(db)
+	accessTokenService := services.NewAccessTokenService(db)
+	loginThrottle := services.NewLoginThrottleService(db, services.LoginThrottlePolicy{
+		MaxAccountFailures: cfg.LoginMaxFailures,
+		MaxIPFailures:      cfg.LoginIPMaxFailures,
+		BackoffBase:        cfg.LoginBackoffBase,
+		BackoffMax:         cfg.LoginBackoffMax,
+		LockoutDuration:    cfg.LoginLockoutDuration,
+		FailureWindow:      cfg.LoginFailureWindow,
+	}, time.Now)
 	curriculumService := services.NewCurriculumService(db)
 	projectService := services.NewProjectService(db)
 	progressService := services.NewProgressService(db)
 	noteService := services.NewNoteService(db)
 	analyticsService := services.NewAnalyticsService(db)
 
-	authHandler := handlers.NewAuthHandler(authService, cfg)
+	authHandler := handlers.NewAuthHandler(authService, sessionService, loginThrottle, accessTokenService, mail, cfg)
 	curriculumHandler := handlers.NewCurriculumHandler(curriculumService, projectService)
 	projectHandler := handlers.NewProjectHandler(projectService, noteService)
 	progressHandler := handlers.NewProgressHandler(progressService)
 	noteHandler := handlers.NewNoteHandler(noteService)
-	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, curriculumService)
+	adminHandler := handlers.NewAdminHandler(authService)
+
+	var rateLimits middleware.RateLimitStore = middleware.NewMemoryRateLimitStore(time.Now)
+	if cfg.RateLimitStore == "postgres" {
+		rateLimits = services.NewRateLimitService(db, time.Now)
+	}
 
 	router := mux.NewRouter()
 
@@ -33,39 +52,85 @@ func Setup(db *sql.DB, cfg *config.Config) *mux.Router {
 
 	api := router.PathPrefix("/api/v1").Subrouter()
 
-	api.HandleFunc("/auth/register", authHandler.Register).Methods("POST", "OPTIONS")
-	api.HandleFunc("/auth/login", authHandler.Login).Methods("POST", "OPTIONS")
+	public := api.NewRoute().Subrouter()
+	public.Use(middleware.RateLimit(rateLimits, "public", cfg.PublicRateLimit, cfg.TrustedProxies))
+
+	public.HandleFunc("/auth/register", authHandler.Register).Methods("POST", "OPTIONS")
+	public.HandleFunc("/auth/login", authHandler.Login).Methods("POST", "OPTIONS")
+	public.HandleFunc("/auth/refresh", authHandler.Refresh).Methods("POST", "OPTIONS")
+	public.HandleFunc("/auth/password/forgot", authHandler.ForgotPassword).Methods("POST", "OPTIONS")
+	public.HandleFunc("/auth/password/reset", authHandler.ResetPassword).Methods("POST", "OPTIONS")
+	public.HandleFunc("/auth/verify-email", authHandler.VerifyEmail).Methods("POST", "OPTIONS")
+
+	// scoped declares the scope a route's token must carry
+	scoped := func(scope string, handler http.HandlerFunc) http.Handler {
+		return middleware.RequireScope(scope)(handler)
+	}
 
 	protected := api.NewRoute().Subrouter()
-	protected.Use(middleware.Auth(cfg.JWTSecret))
+	protected.Use(middleware.Auth(cfg.JWTSecret, sessionService, accessTokenService))
+	protected.Use(middleware.RateLimit(rateLimits, "api", cfg.APIRateLimit, cfg.TrustedProxies))
 
 	protected.HandleFunc("/auth/me", authHandler.Me).Methods("GET", "OPTIONS")
-
-	protected.HandleFunc("/curricula", curriculumHandler.CreateCurriculum).Methods("POST", "OPTIONS")
-	protected.HandleFunc("/curricula", curriculumHandler.GetCurricula).Methods("GET", "OPTIONS")
-	protected.HandleFunc("/curricula/{id:[0-9]+}", curriculumHandler.GetCurriculum).Methods("GET", "OPTIONS")
-	protected.HandleFunc("/curricula/{id:[0-9]+}", curriculumHandler.UpdateCurriculum).Methods("PUT", "OPTIONS")
-	protected.HandleFunc("/curricula/{id:[0-9]+}", curriculumHandler.DeleteCurriculum).Methods("DELETE", "OPTIONS")
-
-	protected.HandleFunc("/curricula/{curriculumId:[0-9]+}/projects", projectHandler.CreateProject).Methods("POST", "OPTIONS")
-	protected.HandleFunc("/projects/{id:[0-9]+}", projectHandler.GetProject).Methods("GET", "OPTIONS")
-	protected.HandleFunc("/projects/{id:[0-9]+}", projectHandler.UpdateProject).Methods("PUT", "OPTIONS")
-	protected.HandleFunc("/projects/{id:[0-9]+}", projectHandler.DeleteProject).Methods("DELETE", "OPTIONS")
-	protected.HandleFunc("/projects/{id:[0-9]+}/notes", projectHandler.GetProjectNotes).Methods("GET", "OPTIONS")
-
-	protected.HandleFunc("/projects/{projectId:[0-9]+}/progress", progressHandler.UpdateProgress).Methods("PUT", "OPTIONS")
-	protected.HandleFunc("/projects/{projectId:[0-9]+}/progress", progressHandler.GetProgress).Methods("GET", "OPTIONS")
-	protected.HandleFunc("/curricula/{curriculumId:[0-9]+}/progress", progressHandler.GetCurriculumProgress).Methods("GET", "OPTIONS")
-
-	protected.HandleFunc("/projects/{projectId:[0-9]+}/notes", noteHandler.CreateNote).Methods("POST", "OPTIONS")
-	protected.HandleFunc("/notes/{id:[0-9]+}", noteHandler.GetNote).Methods("GET", "OPTIONS")
-	protected.HandleFunc("/notes/{id:[0-9]+}", noteHandler.UpdateNote).Methods("PUT", "OPTIONS")
-	protected.HandleFunc("/notes/{id:[0-9]+}", noteHandler.DeleteNote).Methods("DELETE", "OPTIONS")
-
-	protected.HandleFunc("/time-entries", analyticsHandler.CreateTimeEntry).Methods("POST", "OPTIONS")
-	protected.HandleFunc("/projects/{projectId:[0-9]+}/time-entries", analyticsHandler.GetProjectTimeEntries).Methods("GET", "OPTIONS")
-	protected.HandleFunc("/curricula/{curriculumId:[0-9]+}/time-stats", analyticsHandler.GetCurriculumTimeStats).Methods("GET", "OPTIONS")
-	protected.HandleFunc("/analytics/user-stats", analyticsHandler.GetUserStats).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/auth/me", authHandler.UpdateMe).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/auth/me", authHandler.DeleteMe).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/auth/verify-email/resend", authHandler.ResendVerification).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/password", authHandler.ChangePassword).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/logout-all", authHandler.LogoutAll).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/sessions", authHandler.GetSessions).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/auth/sessions/{id:[0-9]+}", authHandler.RevokeSession).Methods("DELETE", "OPTIONS")
+	protected.HandleFunc("/auth/tokens", authHandler.GetAccessTokens).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/auth/tokens", authHandler.CreateAccessToken).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/auth/tokens/{id:[0-9]+}", authHandler.DeleteAccessToken).Methods("DELETE", "OPTIONS")
+
+	protected.Handle("/curricula", scoped(models.ScopeCurriculaWrite, curriculumHandler.CreateCurriculum)).Methods("POST", "OPTIONS")
+	protected.Handle("/curricula", scoped(models.ScopeCurriculaRead, curriculumHandler.GetCurricula)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/import", scoped(models.ScopeCurriculaWrite, curriculumHandler.ImportCurriculum)).Methods("POST", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}", scoped(models.ScopeCurriculaRead, curriculumHandler.GetCurriculum)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}", scoped(models.ScopeCurriculaWrite, curriculumHandler.UpdateCurriculum)).Methods("PUT", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}", scoped(models.ScopeCurriculaWrite, curriculumHandler.DeleteCurriculum)).Methods("DELETE", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/graph", scoped(models.ScopeCurriculaRead, curriculumHandler.GetCurriculumGraph)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/next", scoped(models.ScopeCurriculaRead, curriculumHandler.GetNextProjects)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/export", scoped(models.ScopeCurriculaRead, curriculumHandler.ExportCurriculum)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/clone", scoped(models.ScopeCurriculaWrite, curriculumHandler.CloneCurriculum)).Methods("POST", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/members", scoped(models.ScopeCurriculaRead, curriculumHandler.GetMembers)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/members", scoped(models.ScopeCurriculaWrite, curriculumHandler.AddMember)).Methods("POST", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/members/{userId:[0-9]+}", scoped(models.ScopeCurriculaWrite, curriculumHandler.UpdateMember)).Methods("PUT", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/members/{userId:[0-9]+}", scoped(models.ScopeCurriculaWrite, curriculumHandler.RemoveMember)).Methods("DELETE", "OPTIONS")
+	protected.Handle("/templates", scoped(models.ScopeCurriculaRead, curriculumHandler.GetTemplates)).Methods("GET", "OPTIONS")
+
+	protected.Handle("/curricula/{curriculumId:[0-9]+}/projects", scoped(models.ScopeCurriculaWrite, projectHandler.CreateProject)).Methods("POST", "OPTIONS")
+	protected.Handle("/curricula/{curriculumId:[0-9]+}/projects:batch", scoped(models.ScopeCurriculaWrite, projectHandler.CreateProjectsBatch)).Methods("POST", "OPTIONS")
+	protected.Handle("/curricula/{curriculumId:[0-9]+}/projects/reorder", scoped(models.ScopeCurriculaWrite, projectHandler.ReorderProjects)).Methods("POST", "OPTIONS")
+	protected.Handle("/projects/{id:[0-9]+}", scoped(models.ScopeCurriculaRead, projectHandler.GetProject)).Methods("GET", "OPTIONS")
+	protected.Handle("/projects/{id:[0-9]+}", scoped(models.ScopeCurriculaWrite, projectHandler.UpdateProject)).Methods("PUT", "OPTIONS")
+	protected.Handle("/projects/{id:[0-9]+}", scoped(models.ScopeCurriculaWrite, projectHandler.DeleteProject)).Methods("DELETE", "OPTIONS")
+	protected.Handle("/projects/{id:[0-9]+}/notes", scoped(models.ScopeNotesRead, projectHandler.GetProjectNotes)).Methods("GET", "OPTIONS")
+
+	protected.Handle("/projects/{projectId:[0-9]+}/progress", scoped(models.ScopeProgressWrite, progressHandler.UpdateProgress)).Methods("PUT", "OPTIONS")
+	protected.Handle("/projects/{projectId:[0-9]+}/progress", scoped(models.ScopeProgressRead, progressHandler.GetProgress)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{curriculumId:[0-9]+}/progress", scoped(models.ScopeProgressRead, progressHandler.GetCurriculumProgress)).Methods("GET", "OPTIONS")
+
+	protected.Handle("/projects/{projectId:[0-9]+}/notes", scoped(models.ScopeNotesWrite, noteHandler.CreateNote)).Methods("POST", "OPTIONS")
+	protected.Handle("/notes/{id:[0-9]+}", scoped(models.ScopeNotesRead, noteHandler.GetNote)).Methods("GET", "OPTIONS")
+	protected.Handle("/notes/{id:[0-9]+}", scoped(models.ScopeNotesWrite, noteHandler.UpdateNote)).Methods("PUT", "OPTIONS")
+	protected.Handle("/notes/{id:[0-9]+}", scoped(models.ScopeNotesWrite, noteHandler.DeleteNote)).Methods("DELETE", "OPTIONS")
+
+	protected.Handle("/time-entries", scoped(models.ScopeTimeWrite, analyticsHandler.CreateTimeEntry)).Methods("POST", "OPTIONS")
+	protected.Handle("/projects/{projectId:[0-9]+}/time-entries", scoped(models.ScopeTimeRead, analyticsHandler.GetProjectTimeEntries)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{curriculumId:[0-9]+}/time-stats", scoped(models.ScopeAnalyticsRead, analyticsHandler.GetCurriculumTimeStats)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/forecast", scoped(models.ScopeAnalyticsRead, analyticsHandler.GetCurriculumForecast)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/variance", scoped(models.ScopeAnalyticsRead, analyticsHandler.GetCurriculumVariance)).Methods("GET", "OPTIONS")
+	protected.Handle("/curricula/{id:[0-9]+}/cohort", scoped(models.ScopeAnalyticsRead, analyticsHandler.GetCurriculumCohort)).Methods("GET", "OPTIONS")
+	protected.Handle("/analytics/user-stats", scoped(models.ScopeAnalyticsRead, analyticsHandler.GetUserStats)).Methods("GET", "OPTIONS")
+
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireRole(models.UserRoleAdmin))
+	admin.Use(middleware.RequireScope(models.ScopeAdmin))
+
+	admin.HandleFunc("/users", adminHandler.ListUsers).Methods("GET", "OPTIONS")
+	admin.HandleFunc("/users/{id:[0-9]+}/role", adminHandler.UpdateUserRole).Methods("PUT", "OPTIONS")
 
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
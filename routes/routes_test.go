@@ -0,0 +1,370 @@
+package routes_test
+
+import (
+	"bytes"
+	"curriculum-tracker/config"
+	"curriculum-tracker/internal/testdb"
+	"curriculum-tracker/mailer"
+	"curriculum-tracker/models"
+	"curriculum-tracker/routes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// discardMailer drops every message.
+type discardMailer struct{}
+
+func (discardMailer) Send(msg mailer.Message) error {
+	return nil
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		JWTSecret:            "test-secret",
+		AccessTokenDuration:  15 * time.Minute,
+		RefreshTokenDuration: time.Hour,
+		AllowedOrigins:       []string{"http://localhost:3000"},
+		PasswordResetTTL:     time.Hour,
+		EmailVerificationTTL: time.Hour,
+		AccountDeletionGrace: time.Hour,
+		LoginMaxFailures:     5,
+		LoginIPMaxFailures:   20,
+		LoginBackoffBase:     time.Second,
+		LoginBackoffMax:      time.Minute,
+		LoginLockoutDuration: 15 * time.Minute,
+		LoginFailureWindow:   time.Hour,
+		RateLimitStore:       "memory",
+	}
+}
+
+// client sends requests to the API as one user.
+type client struct {
+	router http.Handler
+	token  string
+	user   models.User
+}
+
+func (c *client) do(t *testing.T, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("failed to encode body: %v", err)
+		}
+	}
+
+	r := httptest.NewRequest(method, "/api/v1"+path, &buf)
+	r.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		r.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, r)
+	return w
+}
+
+// must sends a request that has to succeed and decodes its data into out.
+func (c *client) must(t *testing.T, method, path string, body, out interface{}) {
+	t.Helper()
+
+	w := c.do(t, method, path, body)
+	if w.Code >= 300 {
+		t.Fatalf("%s %s = %d: %s", method, path, w.Code, w.Body.String())
+	}
+	if out == nil {
+		return
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		t.Fatalf("failed to decode data: %v", err)
+	}
+}
+
+func register(t *testing.T, router http.Handler, name string) *client {
+	t.Helper()
+
+	c := &client{router: router}
+	var login models.LoginResponse
+	c.must(t, http.MethodPost, "/auth/register", models.CreateUserRequest{
+		Email:    testdb.Email(),
+		Password: "password123",
+		Name:     name,
+	}, &login)
+	c.token = login.Token
+	c.user = login.User
+	return c
+}
+
+// TestCrossUserAccess has one user try every route that names a resource on
+// another user's curriculum, project, note, session and token. None of them
+// may succeed, and the resources must respond as if they did not exist.
+func TestCrossUserAccess(t *testing.T) {
+	db := testdb.Open(t)
+	router := routes.Setup(db, testConfig(), discardMailer{})
+
+	owner := register(t, router, "Owner")
+	stranger := register(t, router, "Stranger")
+
+	var curriculum models.Curriculum
+	owner.must(t, http.MethodPost, "/curricula", models.CreateCurriculumRequest{Name: "Private"}, &curriculum)
+
+	var project models.Project
+	owner.must(t, http.MethodPost, fmt.Sprintf("/curricula/%d/projects", curriculum.ID), models.CreateProjectRequest{
+		Name:        "Root",
+		ProjectType: models.ProjectTypeRoot,
+	}, &project)
+
+	var note models.Note
+	owner.must(t, http.MethodPost, fmt.Sprintf("/projects/%d/notes", project.ID), models.CreateNoteRequest{
+		Content: "Private note",
+	}, &note)
+
+	owner.must(t, http.MethodPut, fmt.Sprintf("/projects/%d/progress", project.ID), models.UpdateProgressRequest{
+		Status:               models.StatusInProgress,
+		CompletionPercentage: 10,
+	}, nil)
+
+	owner.must(t, http.MethodPost, "/time-entries", models.CreateTimeEntryRequest{
+		ProjectID: project.ID,
+		Minutes:   30,
+		Date:      "2025-01-01",
+	}, nil)
+
+	var sessions []models.Session
+	owner.must(t, http.MethodGet, "/auth/sessions", nil, &sessions)
+	if len(sessions) == 0 {
+		t.Fatal("owner has no sessions")
+	}
+
+	var accessToken models.CreateAccessTokenResponse
+	owner.must(t, http.MethodPost, "/auth/tokens", models.CreateAccessTokenRequest{
+		Name:   "CI",
+		Scopes: []string{models.ScopeCurriculaRead},
+	}, &accessToken)
+
+	c := fmt.Sprintf("/curricula/%d", curriculum.ID)
+	p := fmt.Sprintf("/projects/%d", project.ID)
+	n := fmt.Sprintf("/notes/%d", note.ID)
+
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+		want   int
+	}{
+		// Curricula
+		{http.MethodGet, c, nil, http.StatusNotFound},
+		{http.MethodPut, c, models.UpdateCurriculumRequest{Name: "Taken"}, http.StatusNotFound},
+		{http.MethodDelete, c, nil, http.StatusNotFound},
+		{http.MethodGet, c + "/graph", nil, http.StatusNotFound},
+		{http.MethodGet, c + "/next", nil, http.StatusNotFound},
+
+		// Transfer
+		{http.MethodGet, c + "/export", nil, http.StatusNotFound},
+		{http.MethodPost, c + "/clone", models.CloneCurriculumRequest{}, http.StatusNotFound},
+		{http.MethodPost, c + "/clone", models.CloneCurriculumRequest{TargetEmail: stranger.user.Email}, http.StatusNotFound},
+
+		// Members
+		{http.MethodGet, c + "/members", nil, http.StatusNotFound},
+		{http.MethodPost, c + "/members", models.AddMemberRequest{Email: stranger.user.Email, Role: models.MemberRoleEditor}, http.StatusNotFound},
+		{http.MethodPut, fmt.Sprintf("%s/members/%d", c, owner.user.ID), models.UpdateMemberRequest{Role: models.MemberRoleLearner}, http.StatusNotFound},
+		{http.MethodDelete, fmt.Sprintf("%s/members/%d", c, owner.user.ID), nil, http.StatusNotFound},
+		{http.MethodDelete, fmt.Sprintf("%s/members/%d", c, stranger.user.ID), nil, http.StatusNotFound},
+
+		// Projects
+		{http.MethodPost, c + "/projects", models.CreateProjectRequest{Name: "Planted", ProjectType: models.ProjectTypeBase}, http.StatusNotFound},
+		{http.MethodPost, c + "/projects:batch", models.BatchCreateProjectsRequest{
+			Projects: []models.BatchProjectItem{{
+				Key:                  "planted",
+				CreateProjectRequest: models.CreateProjectRequest{Name: "Planted", ProjectType: models.ProjectTypeBase},
+			}},
+		}, http.StatusNotFound},
+		{http.MethodPost, c + "/projects/reorder", models.ReorderProjectsRequest{ProjectIDs: []int{project.ID}}, http.StatusNotFound},
+		{http.MethodGet, p, nil, http.StatusNotFound},
+		{http.MethodPut, p, models.UpdateProjectRequest{Name: "Taken", ProjectType: models.ProjectTypeRoot}, http.StatusNotFound},
+		{http.MethodDelete, p, nil, http.StatusNotFound},
+
+		// Notes
+		{http.MethodGet, p + "/notes", nil, http.StatusNotFound},
+		{http.MethodPost, p + "/notes", models.CreateNoteRequest{Content: "Planted"}, http.StatusNotFound},
+		{http.MethodGet, n, nil, http.StatusNotFound},
+		{http.MethodPut, n, models.UpdateNoteRequest{Content: "Taken"}, http.StatusNotFound},
+		{http.MethodDelete, n, nil, http.StatusNotFound},
+
+		// Progress
+		{http.MethodGet, p + "/progress", nil, http.StatusNotFound},
+		{http.MethodPut, p + "/progress", models.UpdateProgressRequest{Status: models.StatusCompleted, CompletionPercentage: 100}, http.StatusNotFound},
+		{http.MethodGet, c + "/progress", nil, http.StatusNotFound},
+
+		// Time
+		{http.MethodPost, "/time-entries", models.CreateTimeEntryRequest{ProjectID: project.ID, Minutes: 30, Date: "2025-01-01"}, http.StatusNotFound},
+		{http.MethodGet, p + "/time-entries", nil, http.StatusNotFound},
+		{http.MethodGet, c + "/time-stats", nil, http.StatusNotFound},
+		{http.MethodGet, c + "/forecast", nil, http.StatusNotFound},
+		{http.MethodGet, c + "/variance", nil, http.StatusNotFound},
+
+		// Cohort
+		{http.MethodGet, c + "/cohort", nil, http.StatusNotFound},
+
+		// Sessions and tokens
+		{http.MethodDelete, fmt.Sprintf("/auth/sessions/%d", sessions[0].ID), nil, http.StatusNotFound},
+		{http.MethodDelete, fmt.Sprintf("/auth/tokens/%d", accessToken.ID), nil, http.StatusNotFound},
+
+		// Admin
+		{http.MethodPut, fmt.Sprintf("/admin/users/%d/role", owner.user.ID), models.UpdateUserRoleRequest{Role: models.UserRoleAdmin}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := stranger.do(t, tt.method, tt.path, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+
+	// The owner still has everything, unchanged
+	var got models.Curriculum
+	owner.must(t, http.MethodGet, c, nil, &got)
+	if got.Name != "Private" {
+		t.Errorf("curriculum name = %q, want Private", got.Name)
+	}
+
+	var gotProject models.Project
+	owner.must(t, http.MethodGet, p, nil, &gotProject)
+	if gotProject.Name != "Root" {
+		t.Errorf("project name = %q, want Root", gotProject.Name)
+	}
+
+	var gotNote models.Note
+	owner.must(t, http.MethodGet, n, nil, &gotNote)
+	if gotNote.Content != "Private note" {
+		t.Errorf("note content = %q, want Private note", gotNote.Content)
+	}
+
+	var progress models.Progress
+	owner.must(t, http.MethodGet, p+"/progress", nil, &progress)
+	if progress.Status != models.StatusInProgress {
+		t.Errorf("progress status = %q, want %s", progress.Status, models.StatusInProgress)
+	}
+
+	var members []models.CurriculumMember
+	owner.must(t, http.MethodGet, c+"/members", nil, &members)
+	if len(members) != 1 || members[0].UserID != owner.user.ID || members[0].Role != models.MemberRoleOwner {
+		t.Errorf("members = %+v, want only the owner", members)
+	}
+
+	var timeEntries []models.TimeEntry
+	owner.must(t, http.MethodGet, p+"/time-entries", nil, &timeEntries)
+	if len(timeEntries) != 1 {
+		t.Errorf("got %d time entries, want 1", len(timeEntries))
+	}
+
+	var tokens []models.AccessToken
+	owner.must(t, http.MethodGet, "/auth/tokens", nil, &tokens)
+	if len(tokens) != 1 {
+		t.Errorf("got %d access tokens, want 1", len(tokens))
+	}
+}
+
+// TestDemotedAdminLosesAccess checks that a role change applies to tokens
+// issued before it, not only to the next sign-in.
+func TestDemotedAdminLosesAccess(t *testing.T) {
+	db := testdb.Open(t)
+	router := routes.Setup(db, testConfig(), discardMailer{})
+
+	demoted := register(t, router, "Demoted admin")
+	admin := register(t, router, "Admin")
+
+	// Sign in again after the promotion, so the tokens carry the admin role
+	var refreshToken string
+	for _, c := range []*client{demoted, admin} {
+		if _, err := db.Exec(`UPDATE users SET role = $1 WHERE id = $2`, models.UserRoleAdmin, c.user.ID); err != nil {
+			t.Fatalf("failed to promote user: %v", err)
+		}
+		var login models.LoginResponse
+		c.must(t, http.MethodPost, "/auth/login", models.LoginRequest{Email: c.user.Email, Password: "password123"}, &login)
+		c.token = login.Token
+		if c == demoted {
+			refreshToken = login.RefreshToken
+		}
+	}
+
+	demoted.must(t, http.MethodGet, "/admin/users", nil, nil)
+
+	admin.must(t, http.MethodPut, fmt.Sprintf("/admin/users/%d/role", demoted.user.ID), models.UpdateUserRoleRequest{
+		Role: models.UserRoleLearner,
+	}, nil)
+
+	if w := demoted.do(t, http.MethodGet, "/admin/users", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("GET /admin/users with the old token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// Nor can the old session be refreshed into a new token
+	anonymous := &client{router: router}
+	w := anonymous.do(t, http.MethodPost, "/auth/refresh", models.RefreshTokenRequest{RefreshToken: refreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("POST /auth/refresh with the old session = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+
+	// Signing in again gives a learner token
+	var login models.LoginResponse
+	anonymous.must(t, http.MethodPost, "/auth/login", models.LoginRequest{Email: demoted.user.Email, Password: "password123"}, &login)
+	demoted.token = login.Token
+	if w := demoted.do(t, http.MethodGet, "/admin/users", nil); w.Code != http.StatusForbidden {
+		t.Errorf("GET /admin/users as a learner = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+// TestConcurrentLoginAttempts sends wrong passwords for one account all at
+// once. Only one may have its password checked; the rest must be throttled
+// as if they had come one after another.
+func TestConcurrentLoginAttempts(t *testing.T) {
+	db := testdb.Open(t)
+	router := routes.Setup(db, testConfig(), discardMailer{})
+
+	user := register(t, router, "Target")
+	// Its own address, so other tests' logins are not throttled by this one
+	remoteAddr := fmt.Sprintf("concurrent-%d:1234", user.user.ID)
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	codes := make(chan int, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, _ := json.Marshal(models.LoginRequest{Email: user.user.Email, Password: "wrong-password"})
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			r.RemoteAddr = remoteAddr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := make(map[int]int)
+	for code := range codes {
+		counts[code]++
+	}
+	if counts[http.StatusUnauthorized] != 1 || counts[http.StatusTooManyRequests] != attempts-1 {
+		t.Errorf("concurrent login responses = %v, want one %d and %d %d", counts,
+			http.StatusUnauthorized, attempts-1, http.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"curriculum-tracker/utils"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const maxAccessTokenDays = 365
+
+// AccessTokenService manages personal access tokens, long-lived tokens for
+// scripts and editor plugins that authenticate without a session.
+type AccessTokenService struct {
+	db *sql.DB
+}
+
+func NewAccessTokenService(db *sql.DB) *AccessTokenService {
+	return &AccessTokenService{db: db}
+}
+
+// CreateAccessToken issues a personal access token and returns it together
+// with the token itself, which is not stored and cannot be shown again.
+func (s *AccessTokenService) CreateAccessToken(userID int, req models.CreateAccessTokenRequest) (*models.AccessToken, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, "", validationErrorf("name must be between 1 and 100 characters")
+	}
+	scopes, err := accessTokenScopes(req)
+	if err != nil {
+		return nil, "", err
+	}
+	if req.ExpiresInDays != nil && (*req.ExpiresInDays < 1 || *req.ExpiresInDays > maxAccessTokenDays) {
+		return nil, "", validationErrorf("expires_in_days must be between 1 and %d", maxAccessTokenDays)
+	}
+
+	secret, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	token := utils.AccessTokenPrefix + secret
+
+	query := `
+		INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP + $6::integer * INTERVAL '1 day')
+		RETURNING id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+	`
+
+	accessToken, err := scanAccessToken(s.db.QueryRow(query, userID, name,
+		token[:len(utils.AccessTokenPrefix)+8], utils.HashToken(token), models.StringArray(scopes), req.ExpiresInDays))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return accessToken, token, nil
+}
+
+func (s *AccessTokenService) ListAccessTokens(userID int) ([]models.AccessToken, error) {
+	query := `
+		SELECT id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]models.AccessToken, 0)
+	for rows.Next() {
+		accessToken, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access token: %w", err)
+		}
+		tokens = append(tokens, *accessToken)
+	}
+
+	return tokens, nil
+}
+
+func (s *AccessTokenService) DeleteAccessToken(userID, tokenID int) error {
+	result, err := s.db.Exec(`DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// deleteUserAccessTokens deletes every personal access token of a user, for
+// when their credentials change and tokens made with the old ones must stop
+// working.
+func deleteUserAccessTokens(q dbtx, userID int) error {
+	if _, err := q.Exec(`DELETE FROM personal_access_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete access tokens: %w", err)
+	}
+	return nil
+}
+
+// AuthenticateAccessToken returns who a personal access token belongs to, or
+// nil if it is unknown or expired, and records that it was used. It
+// satisfies middleware.AccessTokenStore.
+func (s *AccessTokenService) AuthenticateAccessToken(token string) (*models.AccessTokenOwner, error) {
+	query := `
+		SELECT t.id, t.user_id, u.role, t.scopes
+		FROM personal_access_tokens t
+		JOIN users u ON t.user_id = u.id
+		WHERE t.token_hash = $1 AND (t.expires_at IS NULL OR t.expires_at > CURRENT_TIMESTAMP)
+	`
+
+	var owner models.AccessTokenOwner
+	err := s.db.QueryRow(query, utils.HashToken(token)).Scan(&owner.TokenID, &owner.UserID, &owner.Role, &owner.Scopes)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query access token: %w", err)
+	}
+
+	// A minute's precision is plenty and spares a write on every request
+	_, err = s.db.Exec(`
+		UPDATE personal_access_tokens SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+	`, owner.TokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record access token use: %w", err)
+	}
+
+	return &owner, nil
+}
+
+// accessTokenScopes returns the scopes a new token asks for, in their
+// documented order, expanding the read and write shorthands.
+func accessTokenScopes(req models.CreateAccessTokenRequest) ([]string, error) {
+	requested := req.Scopes
+	if len(requested) == 0 {
+		switch req.Scope {
+		case "read":
+			requested = models.ReadScopes
+		case "write":
+			requested = models.AllScopes
+		case "":
+			return nil, validationErrorf("at least one scope is required")
+		default:
+			return nil, validationErrorf("invalid scope: %s", req.Scope)
+		}
+	}
+
+	wanted := make(map[string]bool, len(requested))
+	for _, scope := range requested {
+		wanted[scope] = true
+	}
+
+	scopes := make([]string, 0, len(wanted))
+	for _, scope := range models.AllScopes {
+		if wanted[scope] {
+			scopes = append(scopes, scope)
+			delete(wanted, scope)
+		}
+	}
+	for _, scope := range requested {
+		if wanted[scope] {
+			return nil, validationErrorf("invalid scope: %s", scope)
+		}
+	}
+
+	return scopes, nil
+}
+
+func scanAccessToken(row rowScanner) (*models.AccessToken, error) {
+	var accessToken models.AccessToken
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&accessToken.ID, &accessToken.Name, &accessToken.Prefix, &accessToken.Scopes,
+		&expiresAt, &lastUsedAt, &accessToken.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		accessToken.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		accessToken.LastUsedAt = &lastUsedAt.Time
+	}
+	return &accessToken, nil
+}
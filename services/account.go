@@ -0,0 +1,448 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"curriculum-tracker/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CreateEmailVerification issues a token that verifies the user's current
+// email address, replacing any sent before.
+func (s *AuthService) CreateEmailVerification(userID int, ttl time.Duration) (*models.User, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var user models.User
+	err = tx.QueryRow(`
+		SELECT id, email, name, role, email_verified_at IS NOT NULL, created_at, updated_at
+		FROM users
+		WHERE id = $1
+		FOR UPDATE
+	`, userID).Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", ErrUserNotFound
+		}
+		return nil, "", fmt.Errorf("failed to query user: %w", err)
+	}
+
+	if user.EmailVerified {
+		return nil, "", validationErrorf("email address is already verified")
+	}
+
+	if _, err := tx.Exec(`DELETE FROM email_verification_tokens WHERE user_id = $1`, user.ID); err != nil {
+		return nil, "", fmt.Errorf("failed to clear verification tokens: %w", err)
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4 * INTERVAL '1 second')
+	`, user.ID, user.Email, utils.HashToken(token), int(ttl.Seconds()))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create verification token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit verification token: %w", err)
+	}
+
+	return &user, token, nil
+}
+
+// VerifyEmail redeems a verification token. A token only works until it
+// expires and only for the address it was sent to; anything else is
+// ErrInvalidVerificationToken.
+func (s *AuthService) VerifyEmail(token string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		SELECT t.user_id
+		FROM email_verification_tokens t
+		JOIN users u ON t.user_id = u.id
+		WHERE t.token_hash = $1 AND t.email = u.email AND t.expires_at > CURRENT_TIMESTAMP
+		FOR UPDATE OF u
+	`, utils.HashToken(token)).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvalidVerificationToken
+		}
+		return fmt.Errorf("failed to query verification token: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM email_verification_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear verification tokens: %w", err)
+	}
+
+	if err := recordAudit(tx, userID, AuditActionEmailVerify, "user", userID, map[string]interface{}{}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit email verification: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateProfile changes a user's name and email. Changing the email needs
+// the current password and marks the account unverified until the new
+// address is confirmed; the previous address is returned so it can be told
+// about the change, and is empty if the email did not change.
+func (s *AuthService) UpdateProfile(userID int, req models.UpdateProfileRequest) (*models.User, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var name, email, passwordHash string
+	err = tx.QueryRow(`
+		SELECT name, email, password_hash FROM users WHERE id = $1 FOR UPDATE
+	`, userID).Scan(&name, &email, &passwordHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", ErrUserNotFound
+		}
+		return nil, "", fmt.Errorf("failed to query user: %w", err)
+	}
+
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > 255 {
+			return nil, "", validationErrorf("name must be between 1 and 255 characters")
+		}
+	}
+
+	previousEmail := ""
+	if req.Email != nil && strings.TrimSpace(*req.Email) != email {
+		newEmail := strings.TrimSpace(*req.Email)
+		if len(newEmail) > 255 || !strings.Contains(newEmail, "@") {
+			return nil, "", validationErrorf("invalid email address")
+		}
+		if !utils.VerifyPassword(req.CurrentPassword, passwordHash) {
+			return nil, "", validationErrorf("current password is incorrect")
+		}
+		previousEmail, email = email, newEmail
+	}
+
+	var user models.User
+	err = tx.QueryRow(`
+		UPDATE users
+		SET name = $1, email = $2, updated_at = CURRENT_TIMESTAMP,
+			email_verified_at = CASE WHEN $3 THEN NULL ELSE email_verified_at END
+		WHERE id = $4
+		RETURNING id, email, name, role, email_verified_at IS NOT NULL, created_at, updated_at
+	`, name, email, previousEmail != "", userID).Scan(
+		&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, "", ErrEmailTaken
+		}
+		return nil, "", fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if previousEmail != "" {
+		if _, err := tx.Exec(`DELETE FROM email_verification_tokens WHERE user_id = $1`, userID); err != nil {
+			return nil, "", fmt.Errorf("failed to clear verification tokens: %w", err)
+		}
+		if err := recordAudit(tx, userID, AuditActionEmailChange, "user", userID, map[string]interface{}{}); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit profile update: %w", err)
+	}
+
+	return &user, previousEmail, nil
+}
+
+// RequestAccountDeletion schedules a user's account for deletion once grace
+// has passed and signs them out everywhere, including their personal access
+// tokens. Signing in again before then cancels the deletion. It returns when
+// the account will be deleted.
+func (s *AuthService) RequestAccountDeletion(userID int, password string, grace time.Duration) (time.Time, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var passwordHash string
+	err = tx.QueryRow(`SELECT password_hash FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&passwordHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, ErrUserNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	if !utils.VerifyPassword(password, passwordHash) {
+		return time.Time{}, validationErrorf("password is incorrect")
+	}
+
+	// Times are written in UTC by the application, like the login throttle's
+	var requestedAt time.Time
+	err = tx.QueryRow(`
+		UPDATE users SET deletion_requested_at = COALESCE(deletion_requested_at, $1)
+		WHERE id = $2
+		RETURNING deletion_requested_at
+	`, time.Now().UTC(), userID).Scan(&requestedAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to request account deletion: %w", err)
+	}
+	deletionDue := asUTC(requestedAt).Add(grace)
+
+	if err := revokeUserSessions(tx, userID, 0); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := deleteUserAccessTokens(tx, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	details := map[string]interface{}{"deletion_due": deletionDue}
+	if err := recordAudit(tx, userID, AuditActionDeletionRequest, "user", userID, details); err != nil {
+		return time.Time{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
+	return deletionDue, nil
+}
+
+func (s *AuthService) cancelAccountDeletion(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE users SET deletion_requested_at = NULL WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+
+	if err := recordAudit(tx, userID, AuditActionDeletionCancel, "user", userID, map[string]interface{}{}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeDeletedAccounts deletes the accounts whose deletion grace period has
+// run out and returns how many it removed. See purgeAccount.
+func (s *AuthService) PurgeDeletedAccounts(grace time.Duration) (int, error) {
+	cutoff := time.Now().UTC().Add(-grace)
+
+	rows, err := s.db.Query(`SELECT id FROM users WHERE deletion_requested_at <= $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query deleted accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to query deleted accounts: %w", err)
+	}
+	rows.Close()
+
+	purged := 0
+	for _, userID := range userIDs {
+		ok, err := s.purgeAccount(userID, cutoff)
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge user %d: %w", userID, err)
+		}
+		if ok {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// purgeAccount deletes a user and, through the foreign keys, all of their
+// data. Curricula they created that others use, because they are templates
+// or have other members, would go with them, so such users are anonymized
+// instead: their personal data is deleted and the row is kept as the
+// curricula's author. A remaining member takes over as owner where nobody
+// else is one; templates with no other members go to an admin, or are
+// unpublished if there is none. It reports false if the deletion was
+// cancelled meanwhile.
+func (s *AuthService) purgeAccount(userID int, cutoff time.Time) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRow(`
+		SELECT email FROM users WHERE id = $1 AND deletion_requested_at <= $2 FOR UPDATE
+	`, userID, cutoff).Scan(&email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM login_throttles WHERE throttle_key = $1`, accountThrottleKey(email)); err != nil {
+		return false, fmt.Errorf("failed to clear login throttle: %w", err)
+	}
+
+	var shared []int64
+	err = tx.QueryRow(`
+		SELECT COALESCE(array_agg(c.id), '{}')
+		FROM curricula c
+		WHERE c.user_id = $1 AND (c.is_template OR EXISTS (
+			SELECT 1 FROM curriculum_members m WHERE m.curriculum_id = c.id AND m.user_id <> $1
+		))
+	`, userID).Scan(pq.Array(&shared))
+	if err != nil {
+		return false, fmt.Errorf("failed to query shared curricula: %w", err)
+	}
+
+	if len(shared) == 0 {
+		if _, err := tx.Exec(`DELETE FROM users WHERE id = $1`, userID); err != nil {
+			return false, fmt.Errorf("failed to delete user: %w", err)
+		}
+		if err := recordAudit(tx, 0, AuditActionAccountDelete, "user", userID, map[string]interface{}{}); err != nil {
+			return false, err
+		}
+	} else {
+		if err := anonymizeUser(tx, userID, shared); err != nil {
+			return false, err
+		}
+		details := map[string]interface{}{"shared_curricula": len(shared)}
+		if err := recordAudit(tx, 0, AuditActionAccountAnonymize, "user", userID, details); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit account purge: %w", err)
+	}
+
+	return true, nil
+}
+
+func anonymizeUser(tx *sql.Tx, userID int, shared []int64) error {
+	_, err := tx.Exec(`DELETE FROM curricula WHERE user_id = $1 AND NOT (id = ANY($2))`, userID, pq.Array(shared))
+	if err != nil {
+		return fmt.Errorf("failed to delete curricula: %w", err)
+	}
+
+	// Editors are preferred over learners, then whoever joined first
+	_, err = tx.Exec(`
+		UPDATE curriculum_members m SET role = 'owner'
+		FROM (
+			SELECT DISTINCT ON (curriculum_id) curriculum_id, user_id
+			FROM curriculum_members
+			WHERE curriculum_id = ANY($2) AND user_id <> $1
+			ORDER BY curriculum_id, role = 'editor' DESC, created_at, user_id
+		) heir
+		WHERE m.curriculum_id = heir.curriculum_id AND m.user_id = heir.user_id
+			AND NOT EXISTS (
+				SELECT 1 FROM curriculum_members o
+				WHERE o.curriculum_id = m.curriculum_id AND o.user_id <> $1 AND o.role = 'owner'
+			)
+	`, userID, pq.Array(shared))
+	if err != nil {
+		return fmt.Errorf("failed to transfer curriculum ownership: %w", err)
+	}
+
+	// Templates nobody else belongs to go to the longest-standing admin, so
+	// someone can still manage what others clone from
+	_, err = tx.Exec(`
+		INSERT INTO curriculum_members (curriculum_id, user_id, role)
+		SELECT c.id, admin.id, 'owner'
+		FROM curricula c, (
+			SELECT id FROM users
+			WHERE role = $3 AND id <> $1 AND deletion_requested_at IS NULL
+			ORDER BY created_at, id
+			LIMIT 1
+		) admin
+		WHERE c.id = ANY($2) AND NOT EXISTS (
+			SELECT 1 FROM curriculum_members m WHERE m.curriculum_id = c.id AND m.user_id <> $1
+		)
+	`, userID, pq.Array(shared), models.UserRoleAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to transfer templates to an admin: %w", err)
+	}
+
+	// Without an admin they are unpublished rather than left with no owner
+	_, err = tx.Exec(`
+		UPDATE curricula c SET is_template = false, updated_at = CURRENT_TIMESTAMP
+		WHERE c.id = ANY($2) AND NOT EXISTS (
+			SELECT 1 FROM curriculum_members m WHERE m.curriculum_id = c.id AND m.user_id <> $1
+		)
+	`, userID, pq.Array(shared))
+	if err != nil {
+		return fmt.Errorf("failed to unpublish templates: %w", err)
+	}
+
+	for _, table := range []string{
+		"curriculum_members", "progress", "notes", "time_entries",
+		"sessions", "personal_access_tokens", "password_reset_tokens", "email_verification_tokens",
+	} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+
+	// An empty password hash never verifies, so the account cannot be used
+	_, err = tx.Exec(`
+		UPDATE users
+		SET email = 'deleted-' || id || '@deleted.invalid', name = 'Deleted user', password_hash = '',
+			role = $2, email_verified_at = NULL, deletion_requested_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID, models.UserRoleLearner)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	return nil
+}
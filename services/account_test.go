@@ -0,0 +1,205 @@
+package services
+
+import (
+	"curriculum-tracker/database"
+	"curriculum-tracker/internal/testdb"
+	"curriculum-tracker/models"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	tests := []struct {
+		name string
+		// withAdmin creates an admin account before the purge
+		withAdmin bool
+	}{
+		{name: "no admin", withAdmin: false},
+		{name: "admin", withAdmin: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A schema of its own, so no other test's admins or pending
+			// deletions take part
+			db := testdb.OpenSchema(t)
+			if err := database.RunMigrations(db); err != nil {
+				t.Fatalf("RunMigrations failed: %v", err)
+			}
+
+			auth := NewAuthService(db)
+			curricula := NewCurriculumService(db)
+
+			createUser := func(name string) *models.User {
+				t.Helper()
+				user, err := auth.CreateUser(models.CreateUserRequest{Email: testdb.Email(), Password: "password123", Name: name})
+				if err != nil {
+					t.Fatalf("failed to create user: %v", err)
+				}
+				return user
+			}
+			createCurriculum := func(userID int, name string, isTemplate bool) int {
+				t.Helper()
+				curriculum, err := curricula.CreateCurriculum(userID, models.CreateCurriculumRequest{Name: name, IsTemplate: isTemplate})
+				if err != nil {
+					t.Fatalf("failed to create curriculum: %v", err)
+				}
+				return curriculum.ID
+			}
+
+			author := createUser("Author")
+			learner := createUser("Learner")
+			bystander := createUser("Bystander")
+
+			var admin *models.User
+			if tt.withAdmin {
+				admin = createUser("Admin")
+				if _, err := db.Exec(`UPDATE users SET role = $1 WHERE id = $2`, models.UserRoleAdmin, admin.ID); err != nil {
+					t.Fatalf("failed to make admin: %v", err)
+				}
+			}
+
+			private := createCurriculum(author.ID, "Private", false)
+			template := createCurriculum(author.ID, "Template", true)
+			shared := createCurriculum(author.ID, "Shared", false)
+			sharedTemplate := createCurriculum(author.ID, "Shared template", true)
+			for _, id := range []int{shared, sharedTemplate} {
+				_, err := curricula.AddMember(author.ID, id, models.AddMemberRequest{Email: learner.Email, Role: models.MemberRoleLearner})
+				if err != nil {
+					t.Fatalf("failed to add member: %v", err)
+				}
+			}
+			bystanderCurriculum := createCurriculum(bystander.ID, "Bystander's", false)
+
+			if _, err := auth.RequestAccountDeletion(author.ID, "password123", time.Hour); err != nil {
+				t.Fatalf("RequestAccountDeletion failed: %v", err)
+			}
+			if _, err := db.Exec(`UPDATE users SET deletion_requested_at = deletion_requested_at - INTERVAL '2 hours' WHERE id = $1`, author.ID); err != nil {
+				t.Fatalf("failed to backdate deletion: %v", err)
+			}
+			// Requested too recently to be purged
+			if _, err := auth.RequestAccountDeletion(bystander.ID, "password123", time.Hour); err != nil {
+				t.Fatalf("RequestAccountDeletion failed: %v", err)
+			}
+
+			purged, err := auth.PurgeDeletedAccounts(time.Hour)
+			if err != nil {
+				t.Fatalf("PurgeDeletedAccounts failed: %v", err)
+			}
+			if purged != 1 {
+				t.Errorf("purged %d accounts, want 1", purged)
+			}
+
+			// The author is anonymized, since others use their curricula
+			var email, name string
+			if err := db.QueryRow(`SELECT email, name FROM users WHERE id = $1`, author.ID).Scan(&email, &name); err != nil {
+				t.Fatalf("failed to query author: %v", err)
+			}
+			if email == author.Email || name != "Deleted user" {
+				t.Errorf("author = %s, %s, want anonymized", email, name)
+			}
+
+			if exists := curriculumExists(t, db, private); exists {
+				t.Error("private curriculum was kept")
+			}
+			if exists := curriculumExists(t, db, bystanderCurriculum); !exists {
+				t.Error("curriculum of an account still in its grace period was deleted")
+			}
+
+			// Every kept curriculum has exactly one owner, and the author is
+			// no member of any
+			for _, id := range []int{template, shared, sharedTemplate} {
+				if exists := curriculumExists(t, db, id); !exists {
+					t.Errorf("curriculum %d was deleted", id)
+					continue
+				}
+				var owners, authorRows int
+				err := db.QueryRow(`
+					SELECT COUNT(*) FILTER (WHERE role = 'owner'), COUNT(*) FILTER (WHERE user_id = $2)
+					FROM curriculum_members WHERE curriculum_id = $1
+				`, id, author.ID).Scan(&owners, &authorRows)
+				if err != nil {
+					t.Fatalf("failed to query members: %v", err)
+				}
+				if authorRows != 0 {
+					t.Errorf("author is still a member of curriculum %d", id)
+				}
+				if id != template || tt.withAdmin {
+					if owners != 1 {
+						t.Errorf("curriculum %d has %d owners, want 1", id, owners)
+					}
+				}
+			}
+
+			// The remaining member takes over where there is one
+			for _, id := range []int{shared, sharedTemplate} {
+				if role := memberRole(t, db, id, learner.ID); role != models.MemberRoleOwner {
+					t.Errorf("learner role in curriculum %d = %q, want owner", id, role)
+				}
+			}
+			if !isTemplate(t, db, sharedTemplate) {
+				t.Error("template with members was unpublished")
+			}
+
+			// A template nobody else belongs to goes to the admin, or is
+			// unpublished without one
+			if tt.withAdmin {
+				if role := memberRole(t, db, template, admin.ID); role != models.MemberRoleOwner {
+					t.Errorf("admin role in template = %q, want owner", role)
+				}
+				if !isTemplate(t, db, template) {
+					t.Error("template handed to the admin was unpublished")
+				}
+			} else {
+				var members int
+				if err := db.QueryRow(`SELECT COUNT(*) FROM curriculum_members WHERE curriculum_id = $1`, template).Scan(&members); err != nil {
+					t.Fatalf("failed to count members: %v", err)
+				}
+				if members != 0 {
+					t.Errorf("template has %d members, want 0", members)
+				}
+				if isTemplate(t, db, template) {
+					t.Error("template with no owner is still published")
+				}
+			}
+
+			// Running again finds nothing to do
+			purged, err = auth.PurgeDeletedAccounts(time.Hour)
+			if err != nil {
+				t.Fatalf("second PurgeDeletedAccounts failed: %v", err)
+			}
+			if purged != 0 {
+				t.Errorf("second run purged %d accounts, want 0", purged)
+			}
+		})
+	}
+}
+
+func curriculumExists(t *testing.T, db *sql.DB, id int) bool {
+	t.Helper()
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM curricula WHERE id = $1)`, id).Scan(&exists); err != nil {
+		t.Fatalf("failed to query curriculum: %v", err)
+	}
+	return exists
+}
+
+func isTemplate(t *testing.T, db *sql.DB, id int) bool {
+	t.Helper()
+	var isTemplate bool
+	if err := db.QueryRow(`SELECT is_template FROM curricula WHERE id = $1`, id).Scan(&isTemplate); err != nil {
+		t.Fatalf("failed to query curriculum: %v", err)
+	}
+	return isTemplate
+}
+
+func memberRole(t *testing.T, db *sql.DB, curriculumID, userID int) string {
+	t.Helper()
+	var role string
+	err := db.QueryRow(`SELECT role FROM curriculum_members WHERE curriculum_id = $1 AND user_id = $2`, curriculumID, userID).Scan(&role)
+	if err != nil && err != sql.ErrNoRows {
+		t.Fatalf("failed to query member: %v", err)
+	}
+	return role
+}
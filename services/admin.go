@@ -0,0 +1,99 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"database/sql"
+	"fmt"
+)
+
+var validUserRoles = map[string]bool{
+	models.UserRoleAdmin:   true,
+	models.UserRoleMentor:  true,
+	models.UserRoleLearner: true,
+}
+
+func (s *AuthService) ListUsers() ([]models.User, error) {
+	query := `
+		SELECT id, email, name, role, email_verified_at IS NOT NULL, created_at, updated_at
+		FROM users
+		ORDER BY name, id
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// UpdateUserRole changes a user's global role and audits the change. Admins
+// cannot change their own role, so the last admin cannot lock everyone out.
+// Access tokens carry the role, so a change signs the user out everywhere;
+// personal access tokens read it on every request and keep working.
+func (s *AuthService) UpdateUserRole(adminID, userID int, req models.UpdateUserRoleRequest) (*models.User, error) {
+	if !validUserRoles[req.Role] {
+		return nil, validationErrorf("invalid role: %s", req.Role)
+	}
+	if adminID == userID {
+		return nil, validationErrorf("you cannot change your own role")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previousRole string
+	err = tx.QueryRow(`SELECT role FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&previousRole)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	query := `
+		UPDATE users
+		SET role = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING id, email, name, role, email_verified_at IS NOT NULL, created_at, updated_at
+	`
+
+	var user models.User
+	err = tx.QueryRow(query, req.Role, userID).Scan(
+		&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	if user.Role != previousRole {
+		if err := revokeUserSessions(tx, userID, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	details := map[string]string{"from": previousRole, "to": user.Role}
+	if err := recordAudit(tx, adminID, AuditActionUserRoleChange, "user", userID, details); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit user role: %w", err)
+	}
+
+	return &user, nil
+}
@@ -4,21 +4,30 @@ import (
 	"curriculum-tracker/models"
 	"database/sql"
 	"fmt"
+	"math"
 	"time"
 )
 
 type AnalyticsService struct {
-	db *sql.DB
+	db             *sql.DB
+	projectService *ProjectService
 }
 
 func NewAnalyticsService(db *sql.DB) *AnalyticsService {
-	return &AnalyticsService{db: db}
+	return &AnalyticsService{
+		db:             db,
+		projectService: NewProjectService(db),
+	}
 }
 
 func (s *AnalyticsService) CreateTimeEntry(userID int, req models.CreateTimeEntryRequest) (*models.TimeEntry, error) {
 	parsedDate, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
-		return nil, fmt.Errorf("invalid date format: %w", err)
+		return nil, validationErrorf("invalid date format: %s", req.Date)
+	}
+
+	if _, err := requireProjectRole(s.db, userID, req.ProjectID, rolesAny); err != nil {
+		return nil, err
 	}
 
 	query := `
@@ -40,12 +49,16 @@ func (s *AnalyticsService) CreateTimeEntry(userID int, req models.CreateTimeEntr
 }
 
 func (s *AnalyticsService) GetTimeEntriesByProjectID(userID, projectID int) ([]models.TimeEntry, error) {
+	if _, err := requireProjectRole(s.db, userID, projectID, rolesAny); err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT te.id, te.user_id, te.project_id, te.minutes, te.description, te.date, te.created_at
 		FROM time_entries te
 		JOIN projects p ON te.project_id = p.id
-		JOIN curricula c ON p.curriculum_id = c.id
-		WHERE te.user_id = $1 AND te.project_id = $2 AND c.user_id = $1
+		JOIN curriculum_members m ON m.curriculum_id = p.curriculum_id AND m.user_id = $1
+		WHERE te.user_id = $1 AND te.project_id = $2
 		ORDER BY te.date DESC, te.created_at DESC
 	`
 
@@ -73,6 +86,10 @@ func (s *AnalyticsService) GetTimeEntriesByProjectID(userID, projectID int) ([]m
 }
 
 func (s *AnalyticsService) GetTimeStatsByCurriculumID(userID, curriculumID int) (*models.TimeStats, error) {
+	if _, err := requireCurriculumRole(s.db, userID, curriculumID, rolesAny); err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT 
 			COALESCE(SUM(te.minutes), 0) as total_minutes,
@@ -82,8 +99,8 @@ func (s *AnalyticsService) GetTimeStatsByCurriculumID(userID, curriculumID int)
 			COALESCE(SUM(te.minutes), 0) as project_minutes
 		FROM time_entries te
 		JOIN projects p ON te.project_id = p.id
-		JOIN curricula c ON p.curriculum_id = c.id
-		WHERE te.user_id = $1 AND c.id = $2 AND c.user_id = $1
+		JOIN curriculum_members m ON m.curriculum_id = p.curriculum_id AND m.user_id = $1
+		WHERE te.user_id = $1 AND p.curriculum_id = $2
 		GROUP BY te.date, p.name
 		ORDER BY te.date DESC
 	`
@@ -145,11 +162,11 @@ func (s *AnalyticsService) GetUserOverallStats(userID int) (map[string]interface
 			COALESCE(SUM(te.minutes), 0) as total_time_minutes,
 			COUNT(DISTINCT n.id) as total_notes
 		FROM curricula c
+		INNER JOIN curriculum_members m ON m.curriculum_id = c.id AND m.user_id = $1
 		LEFT JOIN projects p ON c.id = p.curriculum_id
 		LEFT JOIN progress pr ON p.id = pr.project_id AND pr.user_id = $1
 		LEFT JOIN time_entries te ON p.id = te.project_id AND te.user_id = $1
 		LEFT JOIN notes n ON p.id = n.project_id AND n.user_id = $1
-		WHERE c.user_id = $1
 	`
 
 	var totalCurricula, totalProjects, completedProjects, inProgressProjects, totalTimeMinutes, totalNotes int
@@ -178,3 +195,280 @@ func (s *AnalyticsService) GetUserOverallStats(userID int) (map[string]interface
 		"completion_rate":      completionRate,
 	}, nil
 }
+
+const forecastPaceDays = 28
+
+func (s *AnalyticsService) GetCurriculumForecast(userID, curriculumID int) (*models.CurriculumForecast, error) {
+	projects, err := s.projectService.GetProjectsByCurriculumID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newProjectGraph(projects)
+
+	forecast := &models.CurriculumForecast{
+		CurriculumID:        curriculumID,
+		UnestimatedProjects: make([]string, 0),
+		CriticalPath:        make([]string, 0),
+	}
+
+	logged, err := s.loggedMinutes(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expected effort per remaining project is the midpoint of its estimate,
+	// less whatever has already gone into it if it is under way
+	expected := make([]float64, len(projects))
+	var remainingMin, remainingMax int
+	var remainingExpected float64
+	for i, p := range projects {
+		if g.completed(i) {
+			continue
+		}
+		forecast.RemainingProjects++
+
+		if p.Estimate == nil {
+			forecast.UnestimatedProjects = append(forecast.UnestimatedProjects, p.Identifier)
+			continue
+		}
+
+		spent := 0
+		if g.status(i) == models.StatusInProgress {
+			spent = logged[p.ID]
+		}
+
+		minMinutes, maxMinutes, expectedMinutes := remainingEffort(*p.Estimate, spent)
+		remainingMin += minMinutes
+		remainingMax += maxMinutes
+		expected[i] = expectedMinutes
+		remainingExpected += expected[i]
+	}
+
+	forecast.RemainingHoursMin = float64(remainingMin) / 60
+	forecast.RemainingHoursMax = float64(remainingMax) / 60
+	forecast.RemainingHours = remainingExpected / 60
+
+	// Longest chain of remaining work through the DAG, walked in
+	// topological order. Projects on cycles never get placed and are skipped.
+	longest := make([]float64, len(projects))
+	previous := make([]int, len(projects))
+	end := -1
+	layers, _ := g.layers()
+	for _, layer := range layers {
+		for _, i := range layer {
+			previous[i] = -1
+			if g.completed(i) {
+				continue
+			}
+			for _, p := range g.prereqs[i] {
+				if g.completed(p) {
+					continue
+				}
+				if previous[i] == -1 || longest[p] > longest[previous[i]] {
+					previous[i] = p
+				}
+			}
+
+			longest[i] = expected[i]
+			if previous[i] != -1 {
+				longest[i] += longest[previous[i]]
+			}
+			if end == -1 || longest[i] > longest[end] {
+				end = i
+			}
+		}
+	}
+
+	for i := end; i != -1; i = previous[i] {
+		forecast.CriticalPath = append([]string{projects[i].Identifier}, forecast.CriticalPath...)
+	}
+	if end != -1 {
+		forecast.CriticalPathHours = longest[end] / 60
+	}
+
+	var trailingMinutes int
+	paceQuery := `
+		SELECT COALESCE(SUM(te.minutes), 0)
+		FROM time_entries te
+		JOIN projects p ON te.project_id = p.id
+		WHERE te.user_id = $1 AND p.curriculum_id = $2
+		AND te.date > CURRENT_DATE - $3::integer AND te.date <= CURRENT_DATE
+	`
+	if err := s.db.QueryRow(paceQuery, userID, curriculumID, forecastPaceDays).Scan(&trailingMinutes); err != nil {
+		return nil, fmt.Errorf("failed to query trailing pace: %w", err)
+	}
+
+	forecast.TrailingWeeklyHours = float64(trailingMinutes) / 60 / (forecastPaceDays / 7)
+
+	if trailingMinutes > 0 {
+		minutesPerDay := float64(trailingMinutes) / forecastPaceDays
+		days := int(math.Ceil(remainingExpected / minutesPerDay))
+		projected := time.Now().Truncate(24*time.Hour).AddDate(0, 0, days)
+		forecast.ProjectedCompletionDate = &projected
+	}
+
+	return forecast, nil
+}
+
+// remainingEffort returns the minimum, maximum and expected minutes left on
+// an estimated project once spent minutes have gone into it. None go below
+// zero, since overrunning an estimate does not make the rest of the
+// curriculum any quicker.
+func remainingEffort(estimate models.Estimate, spent int) (int, int, float64) {
+	return max(estimate.MinMinutes-spent, 0),
+		max(estimate.MaxMinutes-spent, 0),
+		max(estimate.ExpectedMinutes()-float64(spent), 0)
+}
+
+// loggedMinutes returns the minutes userID has logged against each project
+// in a curriculum up to today. Entries dated later have not been spent yet.
+func (s *AnalyticsService) loggedMinutes(userID, curriculumID int) (map[int]int, error) {
+	query := `
+		SELECT te.project_id, SUM(te.minutes)
+		FROM time_entries te
+		JOIN projects p ON te.project_id = p.id
+		WHERE te.user_id = $1 AND p.curriculum_id = $2 AND te.date <= CURRENT_DATE
+		GROUP BY te.project_id
+	`
+
+	rows, err := s.db.Query(query, userID, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logged time: %w", err)
+	}
+	defer rows.Close()
+
+	logged := make(map[int]int)
+	for rows.Next() {
+		var projectID, minutes int
+		if err := rows.Scan(&projectID, &minutes); err != nil {
+			return nil, fmt.Errorf("failed to scan logged time: %w", err)
+		}
+		logged[projectID] = minutes
+	}
+
+	return logged, nil
+}
+
+func (s *AnalyticsService) GetCurriculumVariance(userID, curriculumID int) (*models.VarianceReport, error) {
+	projects, err := s.projectService.GetProjectsByCurriculumID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT te.project_id, SUM(te.minutes)
+		FROM time_entries te
+		JOIN projects p ON te.project_id = p.id
+		WHERE te.user_id = $1 AND p.curriculum_id = $2
+		GROUP BY te.project_id
+	`
+
+	rows, err := s.db.Query(query, userID, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query logged time: %w", err)
+	}
+	defer rows.Close()
+
+	actual := make(map[int]int)
+	for rows.Next() {
+		var projectID, minutes int
+		if err := rows.Scan(&projectID, &minutes); err != nil {
+			return nil, fmt.Errorf("failed to scan logged time: %w", err)
+		}
+		actual[projectID] = minutes
+	}
+
+	report := &models.VarianceReport{
+		CurriculumID:  curriculumID,
+		Projects:      make([]models.ProjectVariance, 0, len(projects)),
+		ByProjectType: make([]models.ProjectTypeVariance, 0),
+	}
+
+	// Calibration only looks at completed projects with an estimate, since
+	// unfinished work has not shown its real cost yet
+	typeIndex := make(map[string]int)
+	typeCalibration := make(map[string][2]float64)
+	var calibrationActual, calibrationExpected float64
+
+	for _, p := range projects {
+		status := models.StatusNotStarted
+		if p.Progress != nil {
+			status = p.Progress.Status
+		}
+		completed := status == models.StatusCompleted
+
+		v := models.ProjectVariance{
+			ProjectID:     p.ID,
+			Identifier:    p.Identifier,
+			Name:          p.Name,
+			ProjectType:   p.ProjectType,
+			Status:        status,
+			Estimate:      p.Estimate,
+			ActualMinutes: actual[p.ID],
+			Outcome:       models.VarianceUnestimated,
+		}
+
+		if _, exists := typeIndex[p.ProjectType]; !exists {
+			typeIndex[p.ProjectType] = len(report.ByProjectType)
+			report.ByProjectType = append(report.ByProjectType, models.ProjectTypeVariance{ProjectType: p.ProjectType})
+		}
+		agg := &report.ByProjectType[typeIndex[p.ProjectType]]
+		agg.Projects++
+		agg.ActualMinutes += v.ActualMinutes
+		if completed {
+			agg.CompletedProjects++
+		}
+
+		if p.Estimate != nil {
+			expected := p.Estimate.ExpectedMinutes()
+			agg.EstimatedMinutes += expected
+
+			switch {
+			case v.ActualMinutes > p.Estimate.MaxMinutes:
+				v.Outcome = models.VarianceOver
+			case !completed:
+				v.Outcome = models.VariancePending
+			case v.ActualMinutes < p.Estimate.MinMinutes:
+				v.Outcome = models.VarianceUnder
+			default:
+				v.Outcome = models.VarianceWithin
+			}
+
+			varianceMinutes := float64(v.ActualMinutes) - expected
+			v.VarianceMinutes = &varianceMinutes
+			if expected > 0 {
+				ratio := float64(v.ActualMinutes) / expected
+				v.VarianceRatio = &ratio
+			}
+
+			if completed && expected > 0 {
+				sums := typeCalibration[p.ProjectType]
+				sums[0] += float64(v.ActualMinutes)
+				sums[1] += expected
+				typeCalibration[p.ProjectType] = sums
+
+				calibrationActual += float64(v.ActualMinutes)
+				calibrationExpected += expected
+				report.CalibrationSampleSize++
+			}
+		}
+
+		report.Projects = append(report.Projects, v)
+	}
+
+	for i := range report.ByProjectType {
+		sums := typeCalibration[report.ByProjectType[i].ProjectType]
+		if sums[1] > 0 {
+			factor := sums[0] / sums[1]
+			report.ByProjectType[i].CalibrationFactor = &factor
+		}
+	}
+
+	if calibrationExpected > 0 {
+		factor := calibrationActual / calibrationExpected
+		report.CalibrationFactor = &factor
+	}
+
+	return report, nil
+}
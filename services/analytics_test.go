@@ -0,0 +1,96 @@
+package services
+
+import (
+	"curriculum-tracker/internal/testdb"
+	"curriculum-tracker/models"
+	"testing"
+	"time"
+)
+
+func TestRemainingEffort(t *testing.T) {
+	estimate := models.Estimate{MinMinutes: 60, MaxMinutes: 180}
+
+	tests := []struct {
+		spent        int
+		wantMin      int
+		wantMax      int
+		wantExpected float64
+	}{
+		{spent: 0, wantMin: 60, wantMax: 180, wantExpected: 120},
+		{spent: 30, wantMin: 30, wantMax: 150, wantExpected: 90},
+		{spent: 120, wantMin: 0, wantMax: 60, wantExpected: 0},
+		{spent: 500, wantMin: 0, wantMax: 0, wantExpected: 0},
+	}
+
+	for _, tt := range tests {
+		gotMin, gotMax, gotExpected := remainingEffort(estimate, tt.spent)
+		if gotMin != tt.wantMin || gotMax != tt.wantMax || gotExpected != tt.wantExpected {
+			t.Errorf("remainingEffort with %d spent = %d, %d, %v, want %d, %d, %v", tt.spent,
+				gotMin, gotMax, gotExpected, tt.wantMin, tt.wantMax, tt.wantExpected)
+		}
+	}
+}
+
+func TestGetCurriculumForecastLoggedTime(t *testing.T) {
+	db := testdb.Open(t)
+	user, curriculum := createTestCurriculum(t, db)
+	projects := NewProjectService(db)
+	progress := NewProgressService(db)
+	analytics := NewAnalyticsService(db)
+
+	create := func(projectType, estimate string) *models.Project {
+		t.Helper()
+		project, err := projects.CreateProject(user.ID, curriculum.ID, models.CreateProjectRequest{
+			Name:          "Project",
+			ProjectType:   projectType,
+			EstimatedTime: estimate,
+		})
+		if err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+		return project
+	}
+	start := func(project *models.Project) {
+		t.Helper()
+		_, err := progress.UpdateProgress(user.ID, project.ID, models.UpdateProgressRequest{Status: models.StatusInProgress})
+		if err != nil {
+			t.Fatalf("UpdateProgress failed: %v", err)
+		}
+	}
+	log := func(project *models.Project, minutes int, date time.Time) {
+		t.Helper()
+		_, err := analytics.CreateTimeEntry(user.ID, models.CreateTimeEntryRequest{
+			ProjectID: project.ID,
+			Minutes:   minutes,
+			Date:      date.Format("2006-01-02"),
+		})
+		if err != nil {
+			t.Fatalf("CreateTimeEntry failed: %v", err)
+		}
+	}
+
+	today := time.Now()
+	started := create(models.ProjectTypeRoot, "10h")
+	overrun := create(models.ProjectTypeRoot, "1h")
+	create(models.ProjectTypeRoot, "2h")
+
+	start(started)
+	start(overrun)
+	log(started, 240, today)
+	log(overrun, 90, today.AddDate(0, 0, -1))
+	// Neither spent yet nor part of the recent pace
+	log(started, 600, today.AddDate(0, 0, 7))
+
+	forecast, err := analytics.GetCurriculumForecast(user.ID, curriculum.ID)
+	if err != nil {
+		t.Fatalf("GetCurriculumForecast failed: %v", err)
+	}
+
+	// 10h less 4h logged, 1h less 1.5h floored at zero, and 2h not started
+	if forecast.RemainingHours != 8 {
+		t.Errorf("remaining hours = %v, want 8", forecast.RemainingHours)
+	}
+	if want := 5.5 / 4; forecast.TrailingWeeklyHours != want {
+		t.Errorf("trailing weekly hours = %v, want %v", forecast.TrailingWeeklyHours, want)
+	}
+}
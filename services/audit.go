@@ -0,0 +1,41 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	AuditActionPrerequisiteOverride = "progress.prerequisite_override"
+	AuditActionUserRoleChange       = "user.role_change"
+	AuditActionPasswordChange       = "user.password_change"
+	AuditActionPasswordReset        = "user.password_reset"
+	AuditActionAccountLockout       = "auth.account_lockout"
+	AuditActionIPLockout            = "auth.ip_lockout"
+	AuditActionEmailChange          = "user.email_change"
+	AuditActionEmailVerify          = "user.email_verify"
+	AuditActionDeletionRequest      = "user.deletion_request"
+	AuditActionDeletionCancel       = "user.deletion_cancel"
+	AuditActionAccountDelete        = "user.delete"
+	AuditActionAccountAnonymize     = "user.anonymize"
+)
+
+// recordAudit writes an audit log entry. A zero userID or entityID is stored
+// as NULL, for events with no user or entity behind them.
+func recordAudit(db dbtx, userID int, action, entityType string, entityID int, details interface{}) error {
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit details: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (user_id, action, entity_type, entity_id, details)
+		VALUES (NULLIF($1, 0), $2, $3, NULLIF($4, 0), $5)
+	`
+
+	if _, err := db.Exec(query, userID, action, entityType, entityID, string(payload)); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
@@ -24,12 +24,12 @@ func (s *AuthService) CreateUser(req models.CreateUserRequest) (*models.User, er
 	query := `
 		INSERT INTO users (email, password_hash, name)
 		VALUES ($1, $2, $3)
-		RETURNING id, email, name, created_at, updated_at
+		RETURNING id, email, name, role, email_verified_at IS NOT NULL, created_at, updated_at
 	`
 
 	var user models.User
 	err = s.db.QueryRow(query, req.Email, hashedPassword, req.Name).Scan(
-		&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
@@ -38,26 +38,37 @@ func (s *AuthService) CreateUser(req models.CreateUserRequest) (*models.User, er
 	return &user, nil
 }
 
+// AuthenticateUser checks a user's credentials. Signing in cancels a
+// pending account deletion.
 func (s *AuthService) AuthenticateUser(email, password string) (*models.User, error) {
 	query := `
-		SELECT id, email, password_hash, name, created_at, updated_at
+		SELECT id, email, password_hash, name, role, email_verified_at IS NOT NULL, created_at, updated_at,
+			deletion_requested_at IS NOT NULL
 		FROM users
 		WHERE email = $1
 	`
 
 	var user models.User
+	var deletionRequested bool
 	err := s.db.QueryRow(query, email).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
+		&deletionRequested,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("invalid credentials")
+			return nil, ErrInvalidCredentials
 		}
 		return nil, fmt.Errorf("failed to query user: %w", err)
 	}
 
 	if !utils.VerifyPassword(password, user.PasswordHash) {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+
+	if deletionRequested {
+		if err := s.cancelAccountDeletion(user.ID); err != nil {
+			return nil, err
+		}
 	}
 
 	return &user, nil
@@ -65,18 +76,18 @@ func (s *AuthService) AuthenticateUser(email, password string) (*models.User, er
 
 func (s *AuthService) GetUserByID(userID int) (*models.User, error) {
 	query := `
-		SELECT id, email, name, created_at, updated_at
+		SELECT id, email, name, role, email_verified_at IS NOT NULL, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
 
 	var user models.User
 	err := s.db.QueryRow(query, userID).Scan(
-		&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to query user: %w", err)
 	}
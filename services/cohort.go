@@ -0,0 +1,187 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"database/sql"
+	"fmt"
+)
+
+const DefaultStalledDays = 14
+
+// GetCohortReport summarises how every learner of a curriculum is getting
+// on: completions per project type, current work, recent time and projects
+// that have stalled. An in-progress project is stalled when its learner has
+// logged no time against it for stalledDays days, counting from when it was
+// started if no time was ever logged. Only owners and editors can see it.
+func (s *AnalyticsService) GetCohortReport(userID, curriculumID, stalledDays int) (*models.CohortReport, error) {
+	if _, err := requireCurriculumRole(s.db, userID, curriculumID, rolesEdit); err != nil {
+		return nil, err
+	}
+
+	report := &models.CohortReport{
+		CurriculumID: curriculumID,
+		StalledDays:  stalledDays,
+		Learners:     make([]models.LearnerSummary, 0),
+	}
+
+	// Project types in curriculum order, so every learner lists them the same way
+	typeRows, err := s.db.Query(`
+		SELECT project_type, COUNT(*)
+		FROM projects
+		WHERE curriculum_id = $1
+		GROUP BY project_type
+		ORDER BY MIN(position_order), project_type
+	`, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query project types: %w", err)
+	}
+	defer typeRows.Close()
+
+	var projectTypes []models.ProjectTypeCompletion
+	for typeRows.Next() {
+		var t models.ProjectTypeCompletion
+		if err := typeRows.Scan(&t.ProjectType, &t.Projects); err != nil {
+			return nil, fmt.Errorf("failed to scan project type: %w", err)
+		}
+		report.TotalProjects += t.Projects
+		projectTypes = append(projectTypes, t)
+	}
+	typeRows.Close()
+
+	learnerRows, err := s.db.Query(`
+		SELECT u.id, u.email, u.name
+		FROM curriculum_members m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.curriculum_id = $1 AND m.role = 'learner'
+		ORDER BY u.name, u.id
+	`, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query learners: %w", err)
+	}
+	defer learnerRows.Close()
+
+	byUserID := make(map[int]int)
+	for learnerRows.Next() {
+		l := models.LearnerSummary{
+			ByProjectType: make([]models.ProjectTypeCompletion, len(projectTypes)),
+			InProgress:    make([]models.CohortProject, 0),
+			Stalled:       make([]models.CohortProject, 0),
+		}
+		copy(l.ByProjectType, projectTypes)
+		if err := learnerRows.Scan(&l.UserID, &l.Email, &l.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan learner: %w", err)
+		}
+		byUserID[l.UserID] = len(report.Learners)
+		report.Learners = append(report.Learners, l)
+	}
+	learnerRows.Close()
+
+	if len(report.Learners) == 0 {
+		return report, nil
+	}
+
+	completedRows, err := s.db.Query(`
+		SELECT pr.user_id, p.project_type, COUNT(*)
+		FROM progress pr
+		JOIN projects p ON pr.project_id = p.id
+		WHERE p.curriculum_id = $1 AND pr.status = 'completed'
+		GROUP BY pr.user_id, p.project_type
+	`, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query completions: %w", err)
+	}
+	defer completedRows.Close()
+
+	for completedRows.Next() {
+		var learnerID, completed int
+		var projectType string
+		if err := completedRows.Scan(&learnerID, &projectType, &completed); err != nil {
+			return nil, fmt.Errorf("failed to scan completions: %w", err)
+		}
+		i, isLearner := byUserID[learnerID]
+		if !isLearner {
+			continue
+		}
+		l := &report.Learners[i]
+		l.CompletedProjects += completed
+		for j := range l.ByProjectType {
+			if l.ByProjectType[j].ProjectType == projectType {
+				l.ByProjectType[j].CompletedProjects = completed
+			}
+		}
+	}
+	completedRows.Close()
+
+	timeRows, err := s.db.Query(`
+		SELECT te.user_id,
+			COALESCE(SUM(CASE WHEN te.date > CURRENT_DATE - 7 THEN te.minutes END), 0),
+			COALESCE(SUM(te.minutes), 0)
+		FROM time_entries te
+		JOIN projects p ON te.project_id = p.id
+		WHERE p.curriculum_id = $1 AND te.date > CURRENT_DATE - 30
+		GROUP BY te.user_id
+	`, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent time: %w", err)
+	}
+	defer timeRows.Close()
+
+	for timeRows.Next() {
+		var learnerID, last7, last30 int
+		if err := timeRows.Scan(&learnerID, &last7, &last30); err != nil {
+			return nil, fmt.Errorf("failed to scan recent time: %w", err)
+		}
+		if i, isLearner := byUserID[learnerID]; isLearner {
+			report.Learners[i].MinutesLast7Days = last7
+			report.Learners[i].MinutesLast30Days = last30
+		}
+	}
+	timeRows.Close()
+
+	inProgressRows, err := s.db.Query(`
+		SELECT pr.user_id, p.id, p.identifier, p.name, pr.completion_percentage, pr.started_at,
+			MAX(te.date),
+			COALESCE(MAX(te.date), pr.started_at::date, pr.updated_at::date) <= CURRENT_DATE - $2::integer
+		FROM progress pr
+		JOIN projects p ON pr.project_id = p.id
+		LEFT JOIN time_entries te ON te.project_id = p.id AND te.user_id = pr.user_id
+		WHERE p.curriculum_id = $1 AND pr.status = 'in_progress'
+		GROUP BY pr.user_id, p.id, p.identifier, p.name, p.position_order,
+			pr.completion_percentage, pr.started_at, pr.updated_at
+		ORDER BY p.position_order, p.identifier
+	`, curriculumID, stalledDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query in-progress projects: %w", err)
+	}
+	defer inProgressRows.Close()
+
+	for inProgressRows.Next() {
+		var learnerID int
+		var p models.CohortProject
+		var startedAt, lastTimeEntry sql.NullTime
+		var stalled bool
+		err := inProgressRows.Scan(&learnerID, &p.ProjectID, &p.Identifier, &p.Name,
+			&p.CompletionPercentage, &startedAt, &lastTimeEntry, &stalled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan in-progress project: %w", err)
+		}
+		i, isLearner := byUserID[learnerID]
+		if !isLearner {
+			continue
+		}
+		if startedAt.Valid {
+			p.StartedAt = &startedAt.Time
+		}
+		if lastTimeEntry.Valid {
+			p.LastTimeEntry = &lastTimeEntry.Time
+		}
+
+		l := &report.Learners[i]
+		l.InProgress = append(l.InProgress, p)
+		if stalled {
+			l.Stalled = append(l.Stalled, p)
+		}
+	}
+
+	return report, nil
+}
@@ -7,45 +7,64 @@ import (
 )
 
 type CurriculumService struct {
-	db *sql.DB
+	db             *sql.DB
+	projectService *ProjectService
 }
 
 func NewCurriculumService(db *sql.DB) *CurriculumService {
-	return &CurriculumService{db: db}
+	return &CurriculumService{
+		db:             db,
+		projectService: NewProjectService(db),
+	}
 }
 
 func (s *CurriculumService) CreateCurriculum(userID int, req models.CreateCurriculumRequest) (*models.Curriculum, error) {
 	query := `
-		INSERT INTO curricula (user_id, name, description)
-		VALUES ($1, $2, $3)
-		RETURNING id, user_id, name, description, created_at, updated_at
+		INSERT INTO curricula (user_id, name, description, is_template)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, name, description, is_template, created_at, updated_at
 	`
 
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	var curriculum models.Curriculum
-	err := s.db.QueryRow(query, userID, req.Name, req.Description).Scan(
+	err = tx.QueryRow(query, userID, req.Name, req.Description, req.IsTemplate).Scan(
 		&curriculum.ID, &curriculum.UserID, &curriculum.Name, &curriculum.Description,
-		&curriculum.CreatedAt, &curriculum.UpdatedAt,
+		&curriculum.IsTemplate, &curriculum.CreatedAt, &curriculum.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create curriculum: %w", err)
 	}
 
+	if err := addCurriculumMember(tx, curriculum.ID, userID, models.MemberRoleOwner); err != nil {
+		return nil, err
+	}
+	curriculum.Role = models.MemberRoleOwner
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit curriculum: %w", err)
+	}
+
 	return &curriculum, nil
 }
 
 func (s *CurriculumService) GetCurriculumsByUserID(userID int) ([]models.CurriculumWithStats, error) {
 	query := `
 		SELECT 
-			c.id, c.user_id, c.name, c.description, c.created_at, c.updated_at,
+			c.id, c.user_id, c.name, c.description, c.is_template, m.role, c.created_at, c.updated_at,
 			COUNT(p.id) as total_projects,
 			COUNT(CASE WHEN pr.status = 'completed' THEN 1 END) as completed_projects,
 			COALESCE(SUM(te.minutes), 0) as total_time_spent
 		FROM curricula c
+		INNER JOIN curriculum_members m ON m.curriculum_id = c.id AND m.user_id = $1
 		LEFT JOIN projects p ON c.id = p.curriculum_id
 		LEFT JOIN progress pr ON p.id = pr.project_id AND pr.user_id = $1
 		LEFT JOIN time_entries te ON p.id = te.project_id AND te.user_id = $1
-		WHERE c.user_id = $1
-		GROUP BY c.id, c.user_id, c.name, c.description, c.created_at, c.updated_at
+		GROUP BY c.id, c.user_id, c.name, c.description, c.is_template, m.role, c.created_at, c.updated_at
 		ORDER BY c.created_at DESC
 	`
 
@@ -60,7 +79,7 @@ func (s *CurriculumService) GetCurriculumsByUserID(userID int) ([]models.Curricu
 	for rows.Next() {
 		var c models.CurriculumWithStats
 		err := rows.Scan(
-			&c.ID, &c.UserID, &c.Name, &c.Description, &c.CreatedAt, &c.UpdatedAt,
+			&c.ID, &c.UserID, &c.Name, &c.Description, &c.IsTemplate, &c.Role, &c.CreatedAt, &c.UpdatedAt,
 			&c.TotalProjects, &c.CompletedProjects, &c.TotalTimeSpent,
 		)
 		if err != nil {
@@ -74,19 +93,20 @@ func (s *CurriculumService) GetCurriculumsByUserID(userID int) ([]models.Curricu
 
 func (s *CurriculumService) GetCurriculumByID(userID, curriculumID int) (*models.Curriculum, error) {
 	query := `
-		SELECT id, user_id, name, description, created_at, updated_at
-		FROM curricula
-		WHERE id = $1 AND user_id = $2
+		SELECT c.id, c.user_id, c.name, c.description, c.is_template, m.role, c.created_at, c.updated_at
+		FROM curricula c
+		INNER JOIN curriculum_members m ON m.curriculum_id = c.id AND m.user_id = $2
+		WHERE c.id = $1
 	`
 
 	var curriculum models.Curriculum
 	err := s.db.QueryRow(query, curriculumID, userID).Scan(
 		&curriculum.ID, &curriculum.UserID, &curriculum.Name, &curriculum.Description,
-		&curriculum.CreatedAt, &curriculum.UpdatedAt,
+		&curriculum.IsTemplate, &curriculum.Role, &curriculum.CreatedAt, &curriculum.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("curriculum not found")
+			return nil, ErrCurriculumNotFound
 		}
 		return nil, fmt.Errorf("failed to query curriculum: %w", err)
 	}
@@ -95,32 +115,48 @@ func (s *CurriculumService) GetCurriculumByID(userID, curriculumID int) (*models
 }
 
 func (s *CurriculumService) UpdateCurriculum(userID, curriculumID int, req models.UpdateCurriculumRequest) (*models.Curriculum, error) {
+	// Editors can change the curriculum, but only the owner decides whether
+	// it is published as a template
+	role, err := requireCurriculumRole(s.db, userID, curriculumID, rolesEdit)
+	if err != nil {
+		return nil, err
+	}
+	if req.IsTemplate != nil && role != models.MemberRoleOwner {
+		return nil, ErrInsufficientRole
+	}
+
 	query := `
 		UPDATE curricula
-		SET name = $1, description = $2, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $3 AND user_id = $4
-		RETURNING id, user_id, name, description, created_at, updated_at
+		SET name = $1, description = $2, is_template = COALESCE($3, is_template),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+		RETURNING id, user_id, name, description, is_template, created_at, updated_at
 	`
 
 	var curriculum models.Curriculum
-	err := s.db.QueryRow(query, req.Name, req.Description, curriculumID, userID).Scan(
+	err = s.db.QueryRow(query, req.Name, req.Description, req.IsTemplate, curriculumID).Scan(
 		&curriculum.ID, &curriculum.UserID, &curriculum.Name, &curriculum.Description,
-		&curriculum.CreatedAt, &curriculum.UpdatedAt,
+		&curriculum.IsTemplate, &curriculum.CreatedAt, &curriculum.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("curriculum not found")
+			return nil, ErrCurriculumNotFound
 		}
 		return nil, fmt.Errorf("failed to update curriculum: %w", err)
 	}
+	curriculum.Role = role
 
 	return &curriculum, nil
 }
 
 func (s *CurriculumService) DeleteCurriculum(userID, curriculumID int) error {
-	query := `DELETE FROM curricula WHERE id = $1 AND user_id = $2`
+	if _, err := requireCurriculumRole(s.db, userID, curriculumID, rolesOwner); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM curricula WHERE id = $1`
 
-	result, err := s.db.Exec(query, curriculumID, userID)
+	result, err := s.db.Exec(query, curriculumID)
 	if err != nil {
 		return fmt.Errorf("failed to delete curriculum: %w", err)
 	}
@@ -131,7 +167,7 @@ func (s *CurriculumService) DeleteCurriculum(userID, curriculumID int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("curriculum not found")
+		return ErrCurriculumNotFound
 	}
 
 	return nil
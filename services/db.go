@@ -0,0 +1,19 @@
+package services
+
+import (
+	"database/sql"
+)
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx so helpers can run either
+// standalone or as part of a caller's transaction.
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows so one scan helper
+// can serve single-row and list queries.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
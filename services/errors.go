@@ -0,0 +1,56 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrCurriculumNotFound       = errors.New("curriculum not found")
+	ErrProjectNotFound          = errors.New("project not found")
+	ErrNoteNotFound             = errors.New("note not found")
+	ErrMemberNotFound           = errors.New("member not found")
+	ErrInsufficientRole         = errors.New("insufficient curriculum role")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrInvalidRefreshToken      = errors.New("invalid refresh token")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrInvalidResetToken        = errors.New("invalid password reset token")
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrAccessTokenNotFound      = errors.New("access token not found")
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+	ErrEmailTaken               = errors.New("email already in use")
+)
+
+type PrerequisitesIncompleteError struct {
+	Blocking []models.BlockingPrerequisite
+}
+
+func (e *PrerequisitesIncompleteError) Error() string {
+	return fmt.Sprintf("%d prerequisite(s) not completed", len(e.Blocking))
+}
+
+type DependentProjectsError struct {
+	Dependents []models.DependentProject
+}
+
+func (e *DependentProjectsError) Error() string {
+	return fmt.Sprintf("cannot delete project: %d other projects depend on it", len(e.Dependents))
+}
+
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func validationErrorf(format string, args ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// errAccountUnavailable refuses an email given for another user, such as a
+// clone target or a new member. It reads the same whether or not an account
+// uses the email, so it cannot be used to find out who is registered.
+var errAccountUnavailable = validationErrorf("that email cannot be used here")
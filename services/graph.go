@@ -0,0 +1,298 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// branchPrefixes are the identifier prefixes of the branch project types,
+// the only types that can belong to a branch group.
+var branchPrefixes = map[string]string{
+	models.ProjectTypeLowerBranch:  "LB",
+	models.ProjectTypeMiddleBranch: "MB",
+	models.ProjectTypeUpperBranch:  "UB",
+}
+
+// branchGroupReference is the prerequisite reference that stands for every
+// project in a branch group, e.g. "LB1" for lower branch group 1.
+func branchGroupReference(projectType string, group int) string {
+	return fmt.Sprintf("%s%d", branchPrefixes[projectType], group)
+}
+
+// isUngroupedBranch reports whether a new project is a branch outside any
+// branch group, which is numbered alongside the groups of its type.
+func isUngroupedBranch(req models.CreateProjectRequest) bool {
+	_, isBranch := branchPrefixes[req.ProjectType]
+	return isBranch && req.BranchGroup == nil
+}
+
+// projectGroupReference returns the branch group reference of a project, or
+// an empty string if it is not grouped.
+func projectGroupReference(p models.Project) string {
+	if p.BranchGroup == nil {
+		return ""
+	}
+	if _, isBranch := branchPrefixes[p.ProjectType]; !isBranch {
+		return ""
+	}
+	return branchGroupReference(p.ProjectType, *p.BranchGroup)
+}
+
+// projectGraph is the prerequisite DAG of a single curriculum. Nodes are
+// indexes into projects, which keeps the position_order sorting of the
+// query that loaded them.
+type projectGraph struct {
+	projects     []models.Project
+	byIdentifier map[string]int
+	groups       map[string][]int
+	groupRefs    []string
+	prereqs      [][]int
+	dependents   [][]int
+	dangling     [][]string
+}
+
+func newProjectGraph(projects []models.Project) *projectGraph {
+	g := &projectGraph{
+		projects:     projects,
+		byIdentifier: make(map[string]int, len(projects)),
+		groups:       make(map[string][]int),
+		groupRefs:    make([]string, 0),
+		prereqs:      make([][]int, len(projects)),
+		dependents:   make([][]int, len(projects)),
+		dangling:     make([][]string, len(projects)),
+	}
+
+	for i, p := range projects {
+		if _, exists := g.byIdentifier[p.Identifier]; !exists {
+			g.byIdentifier[p.Identifier] = i
+		}
+
+		if ref := projectGroupReference(p); ref != "" {
+			if _, exists := g.groups[ref]; !exists {
+				g.groupRefs = append(g.groupRefs, ref)
+			}
+			g.groups[ref] = append(g.groups[ref], i)
+		}
+	}
+
+	for i, p := range projects {
+		seen := make(map[int]bool)
+		for _, ref := range p.Prerequisites {
+			ref = strings.TrimSpace(ref)
+			if ref == "" {
+				continue
+			}
+
+			targets := g.resolve(ref)
+			if len(targets) == 0 {
+				g.dangling[i] = append(g.dangling[i], ref)
+				continue
+			}
+
+			for _, j := range targets {
+				if seen[j] {
+					continue
+				}
+				seen[j] = true
+
+				g.prereqs[i] = append(g.prereqs[i], j)
+				g.dependents[j] = append(g.dependents[j], i)
+			}
+		}
+	}
+
+	return g
+}
+
+// resolve returns the nodes a prerequisite reference points at. An exact
+// identifier match wins; otherwise the reference may name a branch group, in
+// which case every project in the group is a prerequisite.
+func (g *projectGraph) resolve(ref string) []int {
+	if j, exists := g.byIdentifier[ref]; exists {
+		return []int{j}
+	}
+	return g.groups[ref]
+}
+
+// layers groups nodes by topological depth. A node lands in a layer once all
+// of its prerequisites are placed; nodes with dangling references, nodes on a
+// cycle and everything downstream of them never do and are returned as
+// unplaced.
+func (g *projectGraph) layers() ([][]int, []int) {
+	remaining := make([]int, len(g.projects))
+	current := make([]int, 0)
+	for i := range g.projects {
+		remaining[i] = len(g.prereqs[i])
+		if len(g.dangling[i]) > 0 {
+			remaining[i]++
+		}
+		if remaining[i] == 0 {
+			current = append(current, i)
+		}
+	}
+
+	placed := make([]bool, len(g.projects))
+	layers := make([][]int, 0)
+	for len(current) > 0 {
+		layers = append(layers, current)
+		next := make([]int, 0)
+		for _, i := range current {
+			placed[i] = true
+			for _, d := range g.dependents[i] {
+				remaining[d]--
+				if remaining[d] == 0 {
+					next = append(next, d)
+				}
+			}
+		}
+		sort.Ints(next)
+		current = next
+	}
+
+	unplaced := make([]int, 0)
+	for i := range g.projects {
+		if !placed[i] {
+			unplaced = append(unplaced, i)
+		}
+	}
+
+	return layers, unplaced
+}
+
+// cycles returns the strongly connected components that contain a cycle,
+// using Tarjan's algorithm.
+func (g *projectGraph) cycles() [][]int {
+	index := 0
+	indexes := make([]int, len(g.projects))
+	lowlinks := make([]int, len(g.projects))
+	onStack := make([]bool, len(g.projects))
+	for i := range indexes {
+		indexes[i] = -1
+	}
+
+	stack := make([]int, 0)
+	cycles := make([][]int, 0)
+
+	var visit func(v int)
+	visit = func(v int) {
+		indexes[v] = index
+		lowlinks[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.prereqs[v] {
+			if indexes[w] == -1 {
+				visit(w)
+				lowlinks[v] = min(lowlinks[v], lowlinks[w])
+			} else if onStack[w] {
+				lowlinks[v] = min(lowlinks[v], indexes[w])
+			}
+		}
+
+		if lowlinks[v] != indexes[v] {
+			return
+		}
+
+		component := make([]int, 0)
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+
+		if len(component) > 1 || g.hasSelfLoop(v) {
+			sort.Ints(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for i := range g.projects {
+		if indexes[i] == -1 {
+			visit(i)
+		}
+	}
+
+	return cycles
+}
+
+func (g *projectGraph) hasSelfLoop(i int) bool {
+	for _, p := range g.prereqs[i] {
+		if p == i {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *projectGraph) completed(i int) bool {
+	return g.status(i) == models.StatusCompleted
+}
+
+// available reports whether a project that is not yet completed has all of
+// its prerequisites completed. except is treated as completed, which lets
+// callers ask what would become available after finishing one project.
+func (g *projectGraph) available(i, except int) bool {
+	if g.completed(i) || len(g.dangling[i]) > 0 {
+		return false
+	}
+	for _, p := range g.prereqs[i] {
+		if p != except && !g.completed(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// downstream returns every node that transitively depends on i.
+func (g *projectGraph) downstream(i int) []int {
+	visited := map[int]bool{i: true}
+	queue := []int{i}
+	nodes := make([]int, 0)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, d := range g.dependents[current] {
+			if visited[d] {
+				continue
+			}
+			visited[d] = true
+			nodes = append(nodes, d)
+			queue = append(queue, d)
+		}
+	}
+	sort.Ints(nodes)
+	return nodes
+}
+
+func (g *projectGraph) identifiers(nodes []int) []string {
+	identifiers := make([]string, 0, len(nodes))
+	for _, i := range nodes {
+		identifiers = append(identifiers, g.projects[i].Identifier)
+	}
+	return identifiers
+}
+
+func (g *projectGraph) status(i int) string {
+	if g.projects[i].Progress == nil {
+		return models.StatusNotStarted
+	}
+	return g.projects[i].Progress.Status
+}
+
+var projectTypeTiers = map[string]int{
+	models.ProjectTypeRoot:            0,
+	models.ProjectTypeRootTest:        0,
+	models.ProjectTypeBase:            1,
+	models.ProjectTypeBaseTest:        1,
+	models.ProjectTypeLowerBranch:     2,
+	models.ProjectTypeMiddleBranch:    2,
+	models.ProjectTypeUpperBranch:     2,
+	models.ProjectTypeFlowerMilestone: 3,
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"reflect"
+	"testing"
+)
+
+// project builds a project for graph tests; status is left as not started
+// when empty.
+func project(identifier, projectType string, group *int, status string, prereqs ...string) models.Project {
+	p := models.Project{
+		Identifier:    identifier,
+		ProjectType:   projectType,
+		BranchGroup:   group,
+		Prerequisites: prereqs,
+	}
+	if status != "" {
+		p.Progress = &models.Progress{Status: status}
+	}
+	return p
+}
+
+func group(n int) *int {
+	return &n
+}
+
+// graphIdentifiers turns layers of nodes into identifiers for comparison.
+func graphIdentifiers(g *projectGraph, layers [][]int) [][]string {
+	out := make([][]string, 0, len(layers))
+	for _, layer := range layers {
+		out = append(out, g.identifiers(layer))
+	}
+	return out
+}
+
+func TestProjectGraphLayers(t *testing.T) {
+	tests := []struct {
+		name     string
+		projects []models.Project
+		layers   [][]string
+		unplaced []string
+		cycles   [][]string
+	}{
+		{
+			name: "chain",
+			projects: []models.Project{
+				project("R1", models.ProjectTypeRoot, nil, ""),
+				project("B1", models.ProjectTypeBase, nil, "", "R1"),
+				project("B2", models.ProjectTypeBase, nil, "", "B1"),
+			},
+			layers:   [][]string{{"R1"}, {"B1"}, {"B2"}},
+			unplaced: []string{},
+			cycles:   [][]string{},
+		},
+		{
+			name: "diamond",
+			projects: []models.Project{
+				project("R1", models.ProjectTypeRoot, nil, ""),
+				project("B1", models.ProjectTypeBase, nil, "", "R1"),
+				project("B2", models.ProjectTypeBase, nil, "", "R1"),
+				project("BT", models.ProjectTypeBaseTest, nil, "", "B1", "B2"),
+			},
+			layers:   [][]string{{"R1"}, {"B1", "B2"}, {"BT"}},
+			unplaced: []string{},
+			cycles:   [][]string{},
+		},
+		{
+			name: "cycle leaves it and its dependents unplaced",
+			projects: []models.Project{
+				project("R1", models.ProjectTypeRoot, nil, ""),
+				project("B1", models.ProjectTypeBase, nil, "", "R1", "B2"),
+				project("B2", models.ProjectTypeBase, nil, "", "B1"),
+				project("B3", models.ProjectTypeBase, nil, "", "B2"),
+			},
+			layers:   [][]string{{"R1"}},
+			unplaced: []string{"B1", "B2", "B3"},
+			cycles:   [][]string{{"B1", "B2"}},
+		},
+		{
+			name: "self loop",
+			projects: []models.Project{
+				project("R1", models.ProjectTypeRoot, nil, "", "R1"),
+			},
+			layers:   [][]string{},
+			unplaced: []string{"R1"},
+			cycles:   [][]string{{"R1"}},
+		},
+		{
+			name: "dangling reference is unreachable",
+			projects: []models.Project{
+				project("R1", models.ProjectTypeRoot, nil, ""),
+				project("B1", models.ProjectTypeBase, nil, "", "R1", "X9"),
+				project("B2", models.ProjectTypeBase, nil, "", "B1"),
+			},
+			layers:   [][]string{{"R1"}},
+			unplaced: []string{"B1", "B2"},
+			cycles:   [][]string{},
+		},
+		{
+			name: "branch group reference",
+			projects: []models.Project{
+				project("R1", models.ProjectTypeRoot, nil, ""),
+				project("LB1", models.ProjectTypeLowerBranch, group(1), "", "R1"),
+				project("LB2", models.ProjectTypeLowerBranch, group(1), "", "R1"),
+				project("LB3", models.ProjectTypeLowerBranch, group(2), "", "R1"),
+				project("F1", models.ProjectTypeFlowerMilestone, nil, "", "LB1"),
+			},
+			// LB1 names the project, not group 1, since identifiers win
+			layers:   [][]string{{"R1"}, {"LB1", "LB2", "LB3"}, {"F1"}},
+			unplaced: []string{},
+			cycles:   [][]string{},
+		},
+		{
+			name: "branch group reference without a matching identifier",
+			projects: []models.Project{
+				project("R1", models.ProjectTypeRoot, nil, ""),
+				project("MB4", models.ProjectTypeMiddleBranch, group(2), "", "R1"),
+				project("MB5", models.ProjectTypeMiddleBranch, group(2), ""),
+				project("F1", models.ProjectTypeFlowerMilestone, nil, "", "MB2"),
+			},
+			layers:   [][]string{{"R1", "MB5"}, {"MB4"}, {"F1"}},
+			unplaced: []string{},
+			cycles:   [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newProjectGraph(tt.projects)
+
+			layers, unplaced := g.layers()
+			if got := graphIdentifiers(g, layers); !reflect.DeepEqual(got, tt.layers) {
+				t.Errorf("layers = %v, want %v", got, tt.layers)
+			}
+			if got := g.identifiers(unplaced); !reflect.DeepEqual(got, tt.unplaced) {
+				t.Errorf("unplaced = %v, want %v", got, tt.unplaced)
+			}
+			if got := graphIdentifiers(g, g.cycles()); !reflect.DeepEqual(got, tt.cycles) {
+				t.Errorf("cycles = %v, want %v", got, tt.cycles)
+			}
+		})
+	}
+}
+
+func TestProjectGraphResolve(t *testing.T) {
+	g := newProjectGraph([]models.Project{
+		project("LB1", models.ProjectTypeLowerBranch, group(3), ""),
+		project("LB2", models.ProjectTypeLowerBranch, group(3), ""),
+		project("UB1", models.ProjectTypeUpperBranch, group(3), ""),
+		project("B1", models.ProjectTypeBase, group(3), ""),
+	})
+
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{ref: "LB1", want: []string{"LB1"}},
+		{ref: "LB3", want: []string{"LB1", "LB2"}},
+		{ref: "UB3", want: []string{"UB1"}},
+		// Only branch types can be grouped
+		{ref: "B3", want: []string{}},
+		{ref: "MB3", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := g.identifiers(g.resolve(tt.ref)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolve(%q) = %v, want %v", tt.ref, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectGraphAvailable(t *testing.T) {
+	g := newProjectGraph([]models.Project{
+		project("R1", models.ProjectTypeRoot, nil, models.StatusCompleted),
+		project("R2", models.ProjectTypeRoot, nil, models.StatusInProgress),
+		project("B1", models.ProjectTypeBase, nil, "", "R1"),
+		project("B2", models.ProjectTypeBase, nil, "", "R1", "R2"),
+		project("B3", models.ProjectTypeBase, nil, "", "R1", "X1"),
+		project("LB1", models.ProjectTypeLowerBranch, group(1), "", "B1"),
+		project("LB2", models.ProjectTypeLowerBranch, group(1), models.StatusCompleted),
+		project("F1", models.ProjectTypeFlowerMilestone, nil, "", "LB1"),
+	})
+
+	tests := []struct {
+		identifier string
+		except     int
+		want       bool
+	}{
+		{identifier: "R1", except: -1, want: false},
+		{identifier: "R2", except: -1, want: true},
+		{identifier: "B1", except: -1, want: true},
+		{identifier: "B2", except: -1, want: false},
+		{identifier: "B2", except: g.byIdentifier["R2"], want: true},
+		{identifier: "B3", except: -1, want: false},
+		{identifier: "F1", except: -1, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.identifier, func(t *testing.T) {
+			if got := g.available(g.byIdentifier[tt.identifier], tt.except); got != tt.want {
+				t.Errorf("available(%s, %d) = %v, want %v", tt.identifier, tt.except, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectGraphDownstream(t *testing.T) {
+	g := newProjectGraph([]models.Project{
+		project("R1", models.ProjectTypeRoot, nil, ""),
+		project("B1", models.ProjectTypeBase, nil, "", "R1"),
+		project("B2", models.ProjectTypeBase, nil, "", "R1"),
+		project("BT", models.ProjectTypeBaseTest, nil, "", "B1", "B2"),
+		project("LB1", models.ProjectTypeLowerBranch, group(1), "", "BT"),
+		project("LB2", models.ProjectTypeLowerBranch, group(1), "", "B2"),
+		project("F1", models.ProjectTypeFlowerMilestone, nil, "", "LB1", "LB2"),
+		project("R2", models.ProjectTypeRoot, nil, ""),
+	})
+
+	tests := []struct {
+		identifier string
+		want       []string
+	}{
+		{identifier: "R1", want: []string{"B1", "B2", "BT", "LB1", "LB2", "F1"}},
+		{identifier: "B1", want: []string{"BT", "LB1", "F1"}},
+		{identifier: "B2", want: []string{"BT", "LB1", "LB2", "F1"}},
+		{identifier: "LB2", want: []string{"F1"}},
+		{identifier: "F1", want: []string{}},
+		{identifier: "R2", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.identifier, func(t *testing.T) {
+			got := g.identifiers(g.downstream(g.byIdentifier[tt.identifier]))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("downstream(%s) = %v, want %v", tt.identifier, got, tt.want)
+			}
+		})
+	}
+}
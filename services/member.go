@@ -0,0 +1,242 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Curricula are shared through curriculum_members. Every member can read a
+// curriculum and track their own progress, notes and time against it;
+// editors can also change its projects, and only the owner can change
+// membership or delete it.
+var (
+	rolesAny   = []string{models.MemberRoleOwner, models.MemberRoleEditor, models.MemberRoleLearner}
+	rolesEdit  = []string{models.MemberRoleOwner, models.MemberRoleEditor}
+	rolesOwner = []string{models.MemberRoleOwner}
+)
+
+var validMemberRoles = map[string]bool{
+	models.MemberRoleEditor:  true,
+	models.MemberRoleLearner: true,
+}
+
+// curriculumRole returns the caller's role in a curriculum, or
+// ErrCurriculumNotFound if they are not a member.
+func curriculumRole(q dbtx, userID, curriculumID int) (string, error) {
+	var role string
+	err := q.QueryRow(
+		`SELECT role FROM curriculum_members WHERE curriculum_id = $1 AND user_id = $2`,
+		curriculumID, userID,
+	).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrCurriculumNotFound
+		}
+		return "", fmt.Errorf("failed to check membership: %w", err)
+	}
+	return role, nil
+}
+
+// requireCurriculumRole checks that the caller holds one of roles in a
+// curriculum. Non-members get ErrCurriculumNotFound, so curricula they cannot
+// see stay hidden; members without a suitable role get ErrInsufficientRole.
+func requireCurriculumRole(q dbtx, userID, curriculumID int, roles []string) (string, error) {
+	role, err := curriculumRole(q, userID, curriculumID)
+	if err != nil {
+		return "", err
+	}
+	for _, allowed := range roles {
+		if role == allowed {
+			return role, nil
+		}
+	}
+	return "", ErrInsufficientRole
+}
+
+// requireProjectRole is requireCurriculumRole for the curriculum a project
+// belongs to. Projects the caller cannot see yield ErrProjectNotFound.
+func requireProjectRole(q dbtx, userID, projectID int, roles []string) (int, error) {
+	var curriculumID int
+	err := q.QueryRow(`SELECT curriculum_id FROM projects WHERE id = $1`, projectID).Scan(&curriculumID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrProjectNotFound
+		}
+		return 0, fmt.Errorf("failed to query project: %w", err)
+	}
+
+	if _, err := requireCurriculumRole(q, userID, curriculumID, roles); err != nil {
+		if errors.Is(err, ErrCurriculumNotFound) {
+			return 0, ErrProjectNotFound
+		}
+		return 0, err
+	}
+
+	return curriculumID, nil
+}
+
+// lockCurriculum checks the caller's role like requireCurriculumRole and then
+// takes a row lock on the curriculum for the rest of the transaction.
+func lockCurriculum(tx *sql.Tx, userID, curriculumID int, roles []string) error {
+	if _, err := requireCurriculumRole(tx, userID, curriculumID, roles); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`SELECT id FROM curricula WHERE id = $1 FOR UPDATE`, curriculumID); err != nil {
+		return fmt.Errorf("failed to lock curriculum: %w", err)
+	}
+
+	return nil
+}
+
+func addCurriculumMember(q dbtx, curriculumID, userID int, role string) error {
+	_, err := q.Exec(`
+		INSERT INTO curriculum_members (curriculum_id, user_id, role)
+		VALUES ($1, $2, $3)
+	`, curriculumID, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to add member: %w", err)
+	}
+	return nil
+}
+
+func (s *CurriculumService) GetMembers(userID, curriculumID int) ([]models.CurriculumMember, error) {
+	if _, err := requireCurriculumRole(s.db, userID, curriculumID, rolesAny); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT u.id, u.email, u.name, m.role, m.created_at
+		FROM curriculum_members m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.curriculum_id = $1
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, u.name, u.id
+	`
+
+	rows, err := s.db.Query(query, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query members: %w", err)
+	}
+	defer rows.Close()
+
+	members := make([]models.CurriculumMember, 0)
+	for rows.Next() {
+		var m models.CurriculumMember
+		if err := rows.Scan(&m.UserID, &m.Email, &m.Name, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	return members, nil
+}
+
+// AddMember adds the user with the given email to a curriculum. An unknown
+// email and a user who is already a member get the same error, so owners
+// cannot use it to find out who has an account.
+func (s *CurriculumService) AddMember(userID, curriculumID int, req models.AddMemberRequest) (*models.CurriculumMember, error) {
+	if _, err := requireCurriculumRole(s.db, userID, curriculumID, rolesOwner); err != nil {
+		return nil, err
+	}
+
+	if !validMemberRoles[req.Role] {
+		return nil, validationErrorf("invalid role: %s", req.Role)
+	}
+
+	var member models.CurriculumMember
+	email := strings.TrimSpace(req.Email)
+	err := s.db.QueryRow(`SELECT id, email, name FROM users WHERE email = $1`, email).
+		Scan(&member.UserID, &member.Email, &member.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errAccountUnavailable
+		}
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+
+	err = s.db.QueryRow(`
+		INSERT INTO curriculum_members (curriculum_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (curriculum_id, user_id) DO NOTHING
+		RETURNING role, created_at
+	`, curriculumID, member.UserID, req.Role).Scan(&member.Role, &member.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errAccountUnavailable
+		}
+		return nil, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	return &member, nil
+}
+
+func (s *CurriculumService) UpdateMember(userID, curriculumID, memberID int, req models.UpdateMemberRequest) (*models.CurriculumMember, error) {
+	if _, err := requireCurriculumRole(s.db, userID, curriculumID, rolesOwner); err != nil {
+		return nil, err
+	}
+
+	if !validMemberRoles[req.Role] {
+		return nil, validationErrorf("invalid role: %s", req.Role)
+	}
+
+	query := `
+		UPDATE curriculum_members m
+		SET role = $1
+		FROM users u
+		WHERE m.curriculum_id = $2 AND m.user_id = $3 AND m.role != 'owner' AND u.id = m.user_id
+		RETURNING u.id, u.email, u.name, m.role, m.created_at
+	`
+
+	var member models.CurriculumMember
+	err := s.db.QueryRow(query, req.Role, curriculumID, memberID).Scan(
+		&member.UserID, &member.Email, &member.Name, &member.Role, &member.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+
+	return &member, nil
+}
+
+// RemoveMember removes a member from a curriculum. The owner can remove
+// anyone else, and every other member can remove themselves. The owner
+// cannot leave; they delete the curriculum instead. The member's progress,
+// notes and time entries are kept in case they rejoin.
+func (s *CurriculumService) RemoveMember(userID, curriculumID, memberID int) error {
+	roles := rolesOwner
+	if memberID == userID {
+		roles = rolesAny
+	}
+	role, err := requireCurriculumRole(s.db, userID, curriculumID, roles)
+	if err != nil {
+		return err
+	}
+	if memberID == userID && role == models.MemberRoleOwner {
+		return validationErrorf("the owner cannot leave the curriculum")
+	}
+
+	result, err := s.db.Exec(
+		`DELETE FROM curriculum_members WHERE curriculum_id = $1 AND user_id = $2 AND role != 'owner'`,
+		curriculumID, memberID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrMemberNotFound
+	}
+
+	return nil
+}
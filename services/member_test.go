@@ -0,0 +1,44 @@
+package services
+
+import (
+	"curriculum-tracker/internal/testdb"
+	"curriculum-tracker/models"
+	"testing"
+)
+
+func TestAddMemberHidesAccounts(t *testing.T) {
+	db := testdb.Open(t)
+	auth := NewAuthService(db)
+	curricula := NewCurriculumService(db)
+
+	owner, err := auth.CreateUser(models.CreateUserRequest{Email: testdb.Email(), Password: "password123", Name: "Owner"})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	member, err := auth.CreateUser(models.CreateUserRequest{Email: testdb.Email(), Password: "password123", Name: "Member"})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	curriculum, err := curricula.CreateCurriculum(owner.ID, models.CreateCurriculumRequest{Name: "Shared"})
+	if err != nil {
+		t.Fatalf("failed to create curriculum: %v", err)
+	}
+
+	add := func(email string) error {
+		_, err := curricula.AddMember(owner.ID, curriculum.ID, models.AddMemberRequest{Email: email, Role: models.MemberRoleLearner})
+		return err
+	}
+
+	if err := add(member.Email); err != nil {
+		t.Fatalf("AddMember failed: %v", err)
+	}
+
+	unknown := add(testdb.Email())
+	existing := add(member.Email)
+	if unknown == nil || existing == nil {
+		t.Fatalf("AddMember errors = %v, %v, want both to fail", unknown, existing)
+	}
+	if unknown.Error() != existing.Error() {
+		t.Errorf("unknown email error %q differs from existing member error %q", unknown, existing)
+	}
+}
@@ -15,6 +15,10 @@ func NewNoteService(db *sql.DB) *NoteService {
 }
 
 func (s *NoteService) CreateNote(userID, projectID int, req models.CreateNoteRequest) (*models.Note, error) {
+	if _, err := requireProjectRole(s.db, userID, projectID, rolesAny); err != nil {
+		return nil, err
+	}
+
 	query := `
 		INSERT INTO notes (user_id, project_id, title, content, note_type)
 		VALUES ($1, $2, $3, $4, $5)
@@ -34,12 +38,16 @@ func (s *NoteService) CreateNote(userID, projectID int, req models.CreateNoteReq
 }
 
 func (s *NoteService) GetNotesByProjectID(userID, projectID int) ([]models.Note, error) {
+	if _, err := requireProjectRole(s.db, userID, projectID, rolesAny); err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT n.id, n.user_id, n.project_id, n.title, n.content, n.note_type, n.created_at, n.updated_at
 		FROM notes n
 		JOIN projects p ON n.project_id = p.id
-		JOIN curricula c ON p.curriculum_id = c.id
-		WHERE n.user_id = $1 AND n.project_id = $2 AND c.user_id = $1
+		JOIN curriculum_members m ON m.curriculum_id = p.curriculum_id AND m.user_id = $1
+		WHERE n.user_id = $1 AND n.project_id = $2
 		ORDER BY n.created_at DESC
 	`
 
@@ -71,8 +79,8 @@ func (s *NoteService) GetNoteByID(userID, noteID int) (*models.Note, error) {
 		SELECT n.id, n.user_id, n.project_id, n.title, n.content, n.note_type, n.created_at, n.updated_at
 		FROM notes n
 		JOIN projects p ON n.project_id = p.id
-		JOIN curricula c ON p.curriculum_id = c.id
-		WHERE n.id = $1 AND n.user_id = $2 AND c.user_id = $2
+		JOIN curriculum_members m ON m.curriculum_id = p.curriculum_id AND m.user_id = $2
+		WHERE n.id = $1 AND n.user_id = $2
 	`
 
 	var note models.Note
@@ -82,7 +90,7 @@ func (s *NoteService) GetNoteByID(userID, noteID int) (*models.Note, error) {
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("note not found")
+			return nil, ErrNoteNotFound
 		}
 		return nil, fmt.Errorf("failed to query note: %w", err)
 	}
@@ -94,9 +102,9 @@ func (s *NoteService) UpdateNote(userID, noteID int, req models.UpdateNoteReques
 	query := `
 		UPDATE notes
 		SET title = $1, content = $2, note_type = $3, updated_at = CURRENT_TIMESTAMP
-		FROM projects p, curricula c
+		FROM projects p, curriculum_members m
 		WHERE notes.id = $4 AND notes.user_id = $5 AND notes.project_id = p.id 
-		      AND p.curriculum_id = c.id AND c.user_id = $5
+		      AND m.curriculum_id = p.curriculum_id AND m.user_id = $5
 		RETURNING notes.id, notes.user_id, notes.project_id, notes.title, notes.content, 
 		         notes.note_type, notes.created_at, notes.updated_at
 	`
@@ -108,7 +116,7 @@ func (s *NoteService) UpdateNote(userID, noteID int, req models.UpdateNoteReques
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("note not found")
+			return nil, ErrNoteNotFound
 		}
 		return nil, fmt.Errorf("failed to update note: %w", err)
 	}
@@ -119,9 +127,9 @@ func (s *NoteService) UpdateNote(userID, noteID int, req models.UpdateNoteReques
 func (s *NoteService) DeleteNote(userID, noteID int) error {
 	query := `
 		DELETE FROM notes
-		USING projects p, curricula c
+		USING projects p, curriculum_members m
 		WHERE notes.id = $1 AND notes.user_id = $2 AND notes.project_id = p.id 
-		      AND p.curriculum_id = c.id AND c.user_id = $2
+		      AND m.curriculum_id = p.curriculum_id AND m.user_id = $2
 	`
 
 	result, err := s.db.Exec(query, noteID, userID)
@@ -135,7 +143,7 @@ func (s *NoteService) DeleteNote(userID, noteID int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("note not found")
+		return ErrNoteNotFound
 	}
 
 	return nil
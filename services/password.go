@@ -0,0 +1,194 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"curriculum-tracker/utils"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const minPasswordLength = 8
+
+func validateNewPassword(password string) error {
+	if len(password) < minPasswordLength {
+		return validationErrorf("password must be at least %d characters", minPasswordLength)
+	}
+	return nil
+}
+
+// ChangePassword replaces a user's password after checking the current one,
+// signs out every other session and deletes the user's personal access
+// tokens. The session the change was made from, keepSessionID, stays
+// signed in.
+func (s *AuthService) ChangePassword(userID, keepSessionID int, req models.ChangePasswordRequest) error {
+	if err := validateNewPassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var passwordHash string
+	err = tx.QueryRow(`SELECT password_hash FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&passwordHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to query user: %w", err)
+	}
+
+	if !utils.VerifyPassword(req.CurrentPassword, passwordHash) {
+		return validationErrorf("current password is incorrect")
+	}
+
+	if err := setPassword(tx, userID, req.NewPassword); err != nil {
+		return err
+	}
+
+	if err := revokeUserSessions(tx, userID, keepSessionID); err != nil {
+		return err
+	}
+
+	if err := deleteUserAccessTokens(tx, userID); err != nil {
+		return err
+	}
+
+	if err := recordAudit(tx, userID, AuditActionPasswordChange, "user", userID, map[string]int{"kept_session_id": keepSessionID}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit password change: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePasswordReset issues a reset token for the account with the given
+// email, replacing any the user has not redeemed yet. It returns
+// ErrUserNotFound for unknown emails; callers should not reveal that.
+func (s *AuthService) CreatePasswordReset(email string, ttl time.Duration) (*models.User, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var user models.User
+	err = tx.QueryRow(`
+		SELECT id, email, name, role, email_verified_at IS NOT NULL, created_at, updated_at
+		FROM users
+		WHERE email = $1
+	`, email).Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", ErrUserNotFound
+		}
+		return nil, "", fmt.Errorf("failed to query user: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, user.ID); err != nil {
+		return nil, "", fmt.Errorf("failed to clear reset tokens: %w", err)
+	}
+
+	token, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP + $3 * INTERVAL '1 second')
+	`, user.ID, utils.HashToken(token), int(ttl.Seconds()))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit reset token: %w", err)
+	}
+
+	return &user, token, nil
+}
+
+// ResetPassword redeems a reset token, setting a new password, signing the
+// user out everywhere and deleting their personal access tokens. A token
+// works once and only until it expires;
+// anything else is ErrInvalidResetToken.
+func (s *AuthService) ResetPassword(req models.ResetPasswordRequest) error {
+	if err := validateNewPassword(req.Password); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		SELECT user_id
+		FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		FOR UPDATE
+	`, utils.HashToken(req.Token)).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to query reset token: %w", err)
+	}
+
+	// Redeeming one token spends every other outstanding one too
+	_, err = tx.Exec(`
+		UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to redeem reset token: %w", err)
+	}
+
+	if err := setPassword(tx, userID, req.Password); err != nil {
+		return err
+	}
+
+	if err := revokeUserSessions(tx, userID, 0); err != nil {
+		return err
+	}
+
+	if err := deleteUserAccessTokens(tx, userID); err != nil {
+		return err
+	}
+
+	if err := recordAudit(tx, userID, AuditActionPasswordReset, "user", userID, map[string]interface{}{}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit password reset: %w", err)
+	}
+
+	return nil
+}
+
+func setPassword(q dbtx, userID int, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	_, err = q.Exec(`
+		UPDATE users SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, hashedPassword, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return nil
+}
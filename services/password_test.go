@@ -0,0 +1,101 @@
+package services
+
+import (
+	"curriculum-tracker/internal/testdb"
+	"curriculum-tracker/models"
+	"testing"
+	"time"
+)
+
+func TestPasswordChangesRevokeCredentials(t *testing.T) {
+	db := testdb.Open(t)
+	auth := NewAuthService(db)
+	sessions := NewSessionService(db)
+	accessTokens := NewAccessTokenService(db)
+
+	tests := []struct {
+		name   string
+		change func(t *testing.T, user *models.User, current *models.Session)
+		// keepsCurrent is whether the session making the change survives it
+		keepsCurrent bool
+	}{
+		{
+			name: "change",
+			change: func(t *testing.T, user *models.User, current *models.Session) {
+				err := auth.ChangePassword(user.ID, current.ID, models.ChangePasswordRequest{
+					CurrentPassword: "password123",
+					NewPassword:     "new-password",
+				})
+				if err != nil {
+					t.Fatalf("ChangePassword failed: %v", err)
+				}
+			},
+			keepsCurrent: true,
+		},
+		{
+			name: "reset",
+			change: func(t *testing.T, user *models.User, current *models.Session) {
+				_, token, err := auth.CreatePasswordReset(user.Email, time.Hour)
+				if err != nil {
+					t.Fatalf("CreatePasswordReset failed: %v", err)
+				}
+				if err := auth.ResetPassword(models.ResetPasswordRequest{Token: token, Password: "new-password"}); err != nil {
+					t.Fatalf("ResetPassword failed: %v", err)
+				}
+			},
+			keepsCurrent: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := auth.CreateUser(models.CreateUserRequest{
+				Email:    testdb.Email(),
+				Password: "password123",
+				Name:     "Test User",
+			})
+			if err != nil {
+				t.Fatalf("failed to create user: %v", err)
+			}
+
+			current, _, err := sessions.CreateSession(user.ID, models.SessionClient{}, time.Hour)
+			if err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+			other, _, err := sessions.CreateSession(user.ID, models.SessionClient{}, time.Hour)
+			if err != nil {
+				t.Fatalf("failed to create session: %v", err)
+			}
+			_, token, err := accessTokens.CreateAccessToken(user.ID, models.CreateAccessTokenRequest{Name: "CI", Scope: "write"})
+			if err != nil {
+				t.Fatalf("failed to create access token: %v", err)
+			}
+
+			tt.change(t, user, current)
+
+			owner, err := accessTokens.AuthenticateAccessToken(token)
+			if err != nil {
+				t.Fatalf("AuthenticateAccessToken failed: %v", err)
+			}
+			if owner != nil {
+				t.Error("access token still works after the password changed")
+			}
+
+			revoked, err := sessions.IsRevoked("", other.ID)
+			if err != nil {
+				t.Fatalf("IsRevoked failed: %v", err)
+			}
+			if !revoked {
+				t.Error("other session was not revoked")
+			}
+
+			revoked, err = sessions.IsRevoked("", current.ID)
+			if err != nil {
+				t.Fatalf("IsRevoked failed: %v", err)
+			}
+			if revoked == tt.keepsCurrent {
+				t.Errorf("current session revoked = %v, want %v", revoked, !tt.keepsCurrent)
+			}
+		})
+	}
+}
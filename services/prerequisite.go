@@ -0,0 +1,214 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Prerequisites are stored as relations: project_prerequisites points at
+// another project by ID, and project_prerequisite_groups points at a branch
+// group by project type and group number. API responses render both as the
+// identifiers and group references clients send, so renaming a project never
+// breaks the projects that depend on it.
+
+// parseBranchGroupReference splits a group reference such as "LB1" into its
+// project type and group number.
+func parseBranchGroupReference(ref string) (string, int, bool) {
+	for projectType, prefix := range branchPrefixes {
+		if !strings.HasPrefix(ref, prefix) {
+			continue
+		}
+		group, err := strconv.Atoi(strings.TrimPrefix(ref, prefix))
+		if err != nil || group < 1 {
+			return "", 0, false
+		}
+		return projectType, group, true
+	}
+	return "", 0, false
+}
+
+// curriculumProjectIDs maps the identifiers of a curriculum's projects to
+// their IDs.
+func curriculumProjectIDs(q dbtx, curriculumID int) (map[string]int, error) {
+	rows, err := q.Query(`SELECT id, identifier FROM projects WHERE curriculum_id = $1`, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var identifier string
+		if err := rows.Scan(&id, &identifier); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		ids[identifier] = id
+	}
+
+	return ids, nil
+}
+
+// setPrerequisites replaces the prerequisites of a project. Each reference
+// is resolved against ids, the identifiers of the curriculum; a reference
+// that is not an identifier is stored as a branch group. References are
+// expected to have been validated already.
+func setPrerequisites(q dbtx, projectID int, refs []string, ids map[string]int) error {
+	if _, err := q.Exec(`DELETE FROM project_prerequisites WHERE project_id = $1`, projectID); err != nil {
+		return fmt.Errorf("failed to clear prerequisites: %w", err)
+	}
+	if _, err := q.Exec(`DELETE FROM project_prerequisite_groups WHERE project_id = $1`, projectID); err != nil {
+		return fmt.Errorf("failed to clear prerequisites: %w", err)
+	}
+
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+
+		if prerequisiteID, exists := ids[ref]; exists {
+			_, err := q.Exec(`
+				INSERT INTO project_prerequisites (project_id, prerequisite_id)
+				VALUES ($1, $2)
+				ON CONFLICT DO NOTHING
+			`, projectID, prerequisiteID)
+			if err != nil {
+				return fmt.Errorf("failed to set prerequisites: %w", err)
+			}
+			continue
+		}
+
+		projectType, group, ok := parseBranchGroupReference(ref)
+		if !ok {
+			return validationErrorf("prerequisite '%s' does not exist in this curriculum", ref)
+		}
+
+		_, err := q.Exec(`
+			INSERT INTO project_prerequisite_groups (project_id, project_type, branch_group)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, projectID, projectType, group)
+		if err != nil {
+			return fmt.Errorf("failed to set prerequisites: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// loadPrerequisites returns the prerequisites of the given projects keyed by
+// project ID. Projects come first in position order, followed by branch group
+// references.
+func loadPrerequisites(q dbtx, projectIDs []int) (map[int]models.StringArray, error) {
+	prerequisites := make(map[int]models.StringArray, len(projectIDs))
+	for _, id := range projectIDs {
+		prerequisites[id] = models.StringArray{}
+	}
+	if len(projectIDs) == 0 {
+		return prerequisites, nil
+	}
+
+	rows, err := q.Query(`
+		SELECT pp.project_id, prereq.identifier
+		FROM project_prerequisites pp
+		JOIN projects prereq ON prereq.id = pp.prerequisite_id
+		WHERE pp.project_id = ANY($1)
+		ORDER BY pp.project_id, prereq.position_order, prereq.identifier
+	`, pq.Array(projectIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prerequisites: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID int
+		var identifier string
+		if err := rows.Scan(&projectID, &identifier); err != nil {
+			return nil, fmt.Errorf("failed to scan prerequisite: %w", err)
+		}
+		prerequisites[projectID] = append(prerequisites[projectID], identifier)
+	}
+	rows.Close()
+
+	groupRows, err := q.Query(`
+		SELECT project_id, project_type, branch_group
+		FROM project_prerequisite_groups
+		WHERE project_id = ANY($1)
+	`, pq.Array(projectIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prerequisites: %w", err)
+	}
+	defer groupRows.Close()
+
+	groups := make(map[int][]string)
+	for groupRows.Next() {
+		var projectID, group int
+		var projectType string
+		if err := groupRows.Scan(&projectID, &projectType, &group); err != nil {
+			return nil, fmt.Errorf("failed to scan prerequisite: %w", err)
+		}
+		groups[projectID] = append(groups[projectID], branchGroupReference(projectType, group))
+	}
+
+	for projectID, refs := range groups {
+		sort.Strings(refs)
+		prerequisites[projectID] = append(prerequisites[projectID], refs...)
+	}
+
+	return prerequisites, nil
+}
+
+// dependentProjects returns the projects that would lose a prerequisite if
+// the given project were deleted: those that depend on it directly, and those
+// that depend on its branch group when it is the group's last member.
+func dependentProjects(q dbtx, projectID int) ([]models.DependentProject, error) {
+	query := `
+		SELECT d.id, d.identifier, d.name
+		FROM projects target
+		JOIN projects d ON d.curriculum_id = target.curriculum_id AND d.id != target.id
+		WHERE target.id = $1
+		AND (
+			EXISTS (
+				SELECT 1 FROM project_prerequisites pp
+				WHERE pp.project_id = d.id AND pp.prerequisite_id = target.id
+			)
+			OR EXISTS (
+				SELECT 1 FROM project_prerequisite_groups pg
+				WHERE pg.project_id = d.id
+				AND pg.project_type = target.project_type
+				AND pg.branch_group = target.branch_group
+				AND NOT EXISTS (
+					SELECT 1 FROM projects other
+					WHERE other.curriculum_id = target.curriculum_id
+					AND other.id != target.id
+					AND other.project_type = target.project_type
+					AND other.branch_group = target.branch_group
+				)
+			)
+		)
+		ORDER BY d.position_order, d.identifier
+	`
+
+	rows, err := q.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	dependents := make([]models.DependentProject, 0)
+	for rows.Next() {
+		var d models.DependentProject
+		if err := rows.Scan(&d.ProjectID, &d.Identifier, &d.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan dependent project: %w", err)
+		}
+		dependents = append(dependents, d)
+	}
+
+	return dependents, nil
+}
@@ -16,6 +16,16 @@ func NewProgressService(db *sql.DB) *ProgressService {
 }
 
 func (s *ProgressService) UpdateProgress(userID, projectID int, req models.UpdateProgressRequest) (*models.Progress, error) {
+	if _, err := requireProjectRole(s.db, userID, projectID, rolesAny); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Get current progress to determine state transitions
 	var currentStatus string
 	var currentStartedAt sql.NullTime
@@ -24,17 +34,37 @@ func (s *ProgressService) UpdateProgress(userID, projectID int, req models.Updat
 		FROM progress 
 		WHERE user_id = $1 AND project_id = $2
 	`
-	err := s.db.QueryRow(getCurrentQuery, userID, projectID).Scan(&currentStatus, &currentStartedAt)
+	err = tx.QueryRow(getCurrentQuery, userID, projectID).Scan(&currentStatus, &currentStartedAt)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, fmt.Errorf("failed to get current progress: %w", err)
 	}
 
+	leavingNotStarted := (currentStatus == "" || currentStatus == models.StatusNotStarted) &&
+		req.Status != models.StatusNotStarted
+
+	// Moving out of not_started requires every prerequisite to be completed
+	// unless the caller explicitly forces the transition. The prerequisites'
+	// progress is locked until the write commits, so one cannot be reverted
+	// in between.
+	var blocking []models.BlockingPrerequisite
+	if leavingNotStarted {
+		if err := lockPrerequisiteProgress(tx, userID, projectID); err != nil {
+			return nil, err
+		}
+		blocking, err = blockingPrerequisites(tx, userID, projectID)
+		if err != nil {
+			return nil, err
+		}
+		if len(blocking) > 0 && !req.Force {
+			return nil, &PrerequisitesIncompleteError{Blocking: blocking}
+		}
+	}
+
 	// Determine started_at and completed_at based on state transitions
 	var startedAt, completedAt sql.NullTime
 
 	// If transitioning from not_started to any other status, set started_at
-	if (currentStatus == "" || currentStatus == models.StatusNotStarted) &&
-		req.Status != models.StatusNotStarted {
+	if leavingNotStarted {
 		startedAt = sql.NullTime{Time: time.Now(), Valid: true}
 	} else if currentStartedAt.Valid {
 		// Preserve existing started_at
@@ -44,15 +74,9 @@ func (s *ProgressService) UpdateProgress(userID, projectID int, req models.Updat
 	// Set completed_at only when transitioning to completed
 	if req.Status == models.StatusCompleted {
 		completedAt = sql.NullTime{Time: time.Now(), Valid: true}
-		req.CompletionPercentage = 100
 	}
 
-	// Validate completion percentage
-	if req.Status == models.StatusNotStarted {
-		req.CompletionPercentage = 0
-	} else if req.Status == models.StatusAbandoned && req.CompletionPercentage == 100 {
-		req.CompletionPercentage = 99 // Can't be 100% if abandoned
-	}
+	req.CompletionPercentage = normalizeCompletion(req.Status, req.CompletionPercentage)
 
 	query := `
 		INSERT INTO progress (user_id, project_id, status, completion_percentage, started_at, completed_at)
@@ -75,7 +99,7 @@ func (s *ProgressService) UpdateProgress(userID, projectID int, req models.Updat
 	`
 
 	var progress models.Progress
-	err = s.db.QueryRow(query, userID, projectID, req.Status, req.CompletionPercentage, startedAt, completedAt).Scan(
+	err = tx.QueryRow(query, userID, projectID, req.Status, req.CompletionPercentage, startedAt, completedAt).Scan(
 		&progress.ID, &progress.UserID, &progress.ProjectID, &progress.Status,
 		&progress.CompletionPercentage, &progress.StartedAt, &progress.CompletedAt,
 		&progress.CreatedAt, &progress.UpdatedAt,
@@ -84,10 +108,29 @@ func (s *ProgressService) UpdateProgress(userID, projectID int, req models.Updat
 		return nil, fmt.Errorf("failed to update progress: %w", err)
 	}
 
+	if len(blocking) > 0 {
+		details := map[string]interface{}{
+			"from_status":            models.StatusNotStarted,
+			"to_status":              req.Status,
+			"blocking_prerequisites": blocking,
+		}
+		if err := recordAudit(tx, userID, AuditActionPrerequisiteOverride, "project", projectID, details); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit progress: %w", err)
+	}
+
 	return &progress, nil
 }
 
 func (s *ProgressService) GetProgressByProjectID(userID, projectID int) (*models.Progress, error) {
+	if _, err := requireProjectRole(s.db, userID, projectID, rolesAny); err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT id, user_id, project_id, status, completion_percentage, started_at, completed_at, created_at, updated_at
 		FROM progress
@@ -117,13 +160,17 @@ func (s *ProgressService) GetProgressByProjectID(userID, projectID int) (*models
 }
 
 func (s *ProgressService) GetProgressByCurriculumID(userID, curriculumID int) ([]models.Progress, error) {
+	if _, err := requireCurriculumRole(s.db, userID, curriculumID, rolesAny); err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT pr.id, pr.user_id, pr.project_id, pr.status, pr.completion_percentage, 
 		       pr.started_at, pr.completed_at, pr.created_at, pr.updated_at
 		FROM progress pr
 		JOIN projects p ON pr.project_id = p.id
-		JOIN curricula c ON p.curriculum_id = c.id
-		WHERE pr.user_id = $1 AND c.id = $2 AND c.user_id = $1
+		JOIN curriculum_members m ON m.curriculum_id = p.curriculum_id AND m.user_id = $1
+		WHERE pr.user_id = $1 AND p.curriculum_id = $2
 		ORDER BY p.position_order, p.created_at
 	`
 
@@ -150,24 +197,97 @@ func (s *ProgressService) GetProgressByCurriculumID(userID, curriculumID int) ([
 	return progressList, nil
 }
 
-func (s *ProgressService) CanStartProject(userID, projectID int) (bool, error) {
-	// Check if all prerequisites are completed
+func (s *ProgressService) GetBlockingPrerequisites(userID, projectID int) ([]models.BlockingPrerequisite, error) {
+	return blockingPrerequisites(s.db, userID, projectID)
+}
+
+// prerequisiteCondition matches the prerequisites, prereq, of project p:
+// those it depends on directly and the members of branch groups it
+// depends on.
+const prerequisiteCondition = `
+	EXISTS (
+		SELECT 1 FROM project_prerequisites pp
+		WHERE pp.project_id = p.id AND pp.prerequisite_id = prereq.id
+	)
+	OR EXISTS (
+		SELECT 1 FROM project_prerequisite_groups pg
+		WHERE pg.project_id = p.id
+		AND pg.project_type = prereq.project_type
+		AND pg.branch_group = prereq.branch_group
+	)
+`
+
+func blockingPrerequisites(q dbtx, userID, projectID int) ([]models.BlockingPrerequisite, error) {
 	query := `
-		SELECT COUNT(*)
+		SELECT prereq.id, prereq.identifier, prereq.name, COALESCE(pr.status, 'not_started')
 		FROM projects p
-		JOIN curricula c ON p.curriculum_id = c.id
-		JOIN unnest(p.prerequisites) AS prereq_id ON true
-		JOIN projects prereq ON prereq.identifier = prereq_id AND prereq.curriculum_id = p.curriculum_id
+		JOIN curriculum_members m ON m.curriculum_id = p.curriculum_id AND m.user_id = $1
+		JOIN projects prereq ON prereq.curriculum_id = p.curriculum_id
 		LEFT JOIN progress pr ON pr.project_id = prereq.id AND pr.user_id = $1
-		WHERE p.id = $2 AND c.user_id = $1
+		WHERE p.id = $2
+		AND (` + prerequisiteCondition + `)
 		AND (pr.status IS NULL OR pr.status != 'completed')
+		ORDER BY prereq.position_order, prereq.identifier
 	`
 
-	var incompletePrereqs int
-	err := s.db.QueryRow(query, userID, projectID).Scan(&incompletePrereqs)
+	rows, err := q.Query(query, userID, projectID)
 	if err != nil {
-		return false, fmt.Errorf("failed to check prerequisites: %w", err)
+		return nil, fmt.Errorf("failed to check prerequisites: %w", err)
 	}
+	defer rows.Close()
 
-	return incompletePrereqs == 0, nil
+	blocking := make([]models.BlockingPrerequisite, 0)
+	for rows.Next() {
+		var b models.BlockingPrerequisite
+		if err := rows.Scan(&b.ProjectID, &b.Identifier, &b.Name, &b.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan prerequisite: %w", err)
+		}
+		blocking = append(blocking, b)
+	}
+
+	return blocking, nil
+}
+
+// lockPrerequisiteProgress share-locks the user's progress on a project's
+// prerequisites. Postgres cannot lock the nullable side of the outer join
+// blockingPrerequisites uses, so the rows are locked separately first.
+func lockPrerequisiteProgress(tx *sql.Tx, userID, projectID int) error {
+	query := `
+		SELECT pr.id
+		FROM projects p
+		JOIN projects prereq ON prereq.curriculum_id = p.curriculum_id
+		JOIN progress pr ON pr.project_id = prereq.id AND pr.user_id = $1
+		WHERE p.id = $2
+		AND (` + prerequisiteCondition + `)
+		FOR SHARE OF pr
+	`
+
+	if _, err := tx.Exec(query, userID, projectID); err != nil {
+		return fmt.Errorf("failed to lock prerequisite progress: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ProgressService) CanStartProject(userID, projectID int) (bool, error) {
+	blocking, err := s.GetBlockingPrerequisites(userID, projectID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(blocking) == 0, nil
+}
+
+// normalizeCompletion makes a completion percentage agree with its status:
+// completed is always 100%, not started 0%, and abandoned can't be 100%.
+func normalizeCompletion(status string, percentage int) int {
+	switch {
+	case status == models.StatusCompleted:
+		return 100
+	case status == models.StatusNotStarted:
+		return 0
+	case status == models.StatusAbandoned && percentage == 100:
+		return 99
+	}
+	return percentage
 }
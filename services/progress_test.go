@@ -0,0 +1,29 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"testing"
+)
+
+func TestNormalizeCompletion(t *testing.T) {
+	tests := []struct {
+		status     string
+		percentage int
+		want       int
+	}{
+		{models.StatusCompleted, 40, 100},
+		{models.StatusCompleted, 100, 100},
+		{models.StatusNotStarted, 40, 0},
+		{models.StatusAbandoned, 100, 99},
+		{models.StatusAbandoned, 40, 40},
+		{models.StatusInProgress, 100, 100},
+		{models.StatusInProgress, 40, 40},
+		{models.StatusOnHold, 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeCompletion(tt.status, tt.percentage); got != tt.want {
+			t.Errorf("normalizeCompletion(%s, %d) = %d, want %d", tt.status, tt.percentage, got, tt.want)
+		}
+	}
+}
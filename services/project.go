@@ -2,8 +2,11 @@ package services
 
 import (
 	"curriculum-tracker/models"
+	"curriculum-tracker/utils"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/lib/pq"
@@ -17,47 +20,62 @@ func NewProjectService(db *sql.DB) *ProjectService {
 	return &ProjectService{db: db}
 }
 
-func (s *ProjectService) generateIdentifier(curriculumID int, projectType string) (string, error) {
+func generateIdentifier(q dbtx, curriculumID int, projectType string, branchGroup *int) (string, error) {
 	switch projectType {
 	case models.ProjectTypeRoot:
-		return s.generateSequentialIdentifier(curriculumID, projectType, "R")
+		return generateSequentialIdentifier(q, curriculumID, projectType, "R")
 	case models.ProjectTypeRootTest:
-		return s.generateTestIdentifier(curriculumID, projectType, "RT")
+		return generateTestIdentifier(q, curriculumID, projectType, "RT")
 	case models.ProjectTypeBase:
-		return s.generateSequentialIdentifier(curriculumID, projectType, "B")
+		return generateSequentialIdentifier(q, curriculumID, projectType, "B")
 	case models.ProjectTypeBaseTest:
-		return s.generateTestIdentifier(curriculumID, projectType, "BT")
+		return generateTestIdentifier(q, curriculumID, projectType, "BT")
 	case models.ProjectTypeLowerBranch:
-		return s.generateBranchIdentifier(curriculumID, projectType, "LB")
+		return generateBranchIdentifier(q, curriculumID, projectType, "LB", branchGroup)
 	case models.ProjectTypeMiddleBranch:
-		return s.generateBranchIdentifier(curriculumID, projectType, "MB")
+		return generateBranchIdentifier(q, curriculumID, projectType, "MB", branchGroup)
 	case models.ProjectTypeUpperBranch:
-		return s.generateBranchIdentifier(curriculumID, projectType, "UB")
+		return generateBranchIdentifier(q, curriculumID, projectType, "UB", branchGroup)
 	case models.ProjectTypeFlowerMilestone:
-		return s.generateSequentialIdentifier(curriculumID, projectType, "F")
+		return generateSequentialIdentifier(q, curriculumID, projectType, "F")
 	default:
 		return "", fmt.Errorf("unknown project type: %s", projectType)
 	}
 }
 
-func (s *ProjectService) generateSequentialIdentifier(curriculumID int, projectType, prefix string) (string, error) {
+// nextIdentifierNumber allocates the next number for a counter within a
+// curriculum. The upsert takes a row lock, so concurrent transactions
+// allocating from the same counter are serialized and never see the same
+// value. Numbers are never reused, even after projects are deleted.
+func nextIdentifierNumber(q dbtx, curriculumID int, counterKey string) (int, error) {
 	query := `
-		SELECT COUNT(*) + 1 
-		FROM projects 
-		WHERE curriculum_id = $1 AND project_type = $2
+		INSERT INTO project_identifier_counters (curriculum_id, counter_key, last_value)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (curriculum_id, counter_key)
+		DO UPDATE SET last_value = project_identifier_counters.last_value + 1
+		RETURNING last_value
 	`
 
-	var nextNum int
-	err := s.db.QueryRow(query, curriculumID, projectType).Scan(&nextNum)
+	var next int
+	if err := q.QueryRow(query, curriculumID, counterKey).Scan(&next); err != nil {
+		return 0, fmt.Errorf("failed to generate identifier: %w", err)
+	}
+
+	return next, nil
+}
+
+func generateSequentialIdentifier(q dbtx, curriculumID int, projectType, prefix string) (string, error) {
+	nextNum, err := nextIdentifierNumber(q, curriculumID, projectType)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate identifier: %w", err)
+		return "", err
 	}
 
 	return fmt.Sprintf("%s%d", prefix, nextNum), nil
 }
 
-func (s *ProjectService) generateTestIdentifier(curriculumID int, projectType, prefix string) (string, error) {
-	// Check if test project already exists
+func generateTestIdentifier(q dbtx, curriculumID int, projectType, prefix string) (string, error) {
+	// Check if test project already exists. A concurrent insert of the same
+	// test project is caught by the unique (curriculum_id, identifier) index.
 	query := `
 		SELECT COUNT(*) 
 		FROM projects 
@@ -65,34 +83,100 @@ func (s *ProjectService) generateTestIdentifier(curriculumID int, projectType, p
 	`
 
 	var count int
-	err := s.db.QueryRow(query, curriculumID, projectType).Scan(&count)
+	err := q.QueryRow(query, curriculumID, projectType).Scan(&count)
 	if err != nil {
 		return "", fmt.Errorf("failed to check existing test projects: %w", err)
 	}
 
 	if count > 0 {
-		return "", fmt.Errorf("test project of type %s already exists in this curriculum", projectType)
+		return "", validationErrorf("test project of type %s already exists in this curriculum", projectType)
 	}
 
 	return prefix, nil
 }
 
-func (s *ProjectService) generateBranchIdentifier(curriculumID int, projectType, prefix string) (string, error) {
-	// For now, using simple sequential numbering like LB1, LB2, LB3
-	// Can be enhanced later to support grouping like LB1_1, LB1_2, LB2_1, LB2_2
-	query := `
-		SELECT COUNT(*) + 1 
-		FROM projects 
-		WHERE curriculum_id = $1 AND project_type = $2
-	`
+func generateBranchIdentifier(q dbtx, curriculumID int, projectType, prefix string, branchGroup *int) (string, error) {
+	// Ungrouped branches are numbered LB1, LB2, LB3; grouped branches are
+	// numbered within their group like LB1_1, LB1_2, LB2_1. An ungrouped LB1
+	// and group 1 would both be referenced as "LB1", so the two never share
+	// a number.
+	if branchGroup == nil {
+		for {
+			nextNum, err := nextIdentifierNumber(q, curriculumID, projectType)
+			if err != nil {
+				return "", err
+			}
 
-	var nextNum int
-	err := s.db.QueryRow(query, curriculumID, projectType).Scan(&nextNum)
+			var groupExists bool
+			err = q.QueryRow(
+				`SELECT EXISTS(SELECT 1 FROM projects WHERE curriculum_id = $1 AND project_type = $2 AND branch_group = $3)`,
+				curriculumID, projectType, nextNum,
+			).Scan(&groupExists)
+			if err != nil {
+				return "", fmt.Errorf("failed to check branch groups: %w", err)
+			}
+			if !groupExists {
+				return fmt.Sprintf("%s%d", prefix, nextNum), nil
+			}
+		}
+	}
+
+	groupReference := fmt.Sprintf("%s%d", prefix, *branchGroup)
+	var identifierExists bool
+	err := q.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM projects WHERE curriculum_id = $1 AND identifier = $2)`,
+		curriculumID, groupReference,
+	).Scan(&identifierExists)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate identifier: %w", err)
+		return "", fmt.Errorf("failed to check existing identifiers: %w", err)
+	}
+	if identifierExists {
+		return "", validationErrorf("branch group %d clashes with the existing project %s", *branchGroup, groupReference)
 	}
 
-	return fmt.Sprintf("%s%d", prefix, nextNum), nil
+	nextNum, err := nextIdentifierNumber(q, curriculumID, fmt.Sprintf("%s:%d", projectType, *branchGroup))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s_%d", groupReference, nextNum), nil
+}
+
+// validateBranchGroup checks the optional branch group of a new project. Only
+// branch projects can be grouped, and a project cannot list its own group as
+// a prerequisite since that would make it depend on itself.
+func validateBranchGroup(projectType string, branchGroup *int, prerequisites []string) error {
+	if branchGroup == nil {
+		return nil
+	}
+
+	if _, isBranch := branchPrefixes[projectType]; !isBranch {
+		return validationErrorf("branch group is only allowed for branch projects")
+	}
+
+	if *branchGroup < 1 {
+		return validationErrorf("branch group must be a positive number")
+	}
+
+	ownGroup := branchGroupReference(projectType, *branchGroup)
+	for _, prereq := range prerequisites {
+		if strings.TrimSpace(prereq) == ownGroup {
+			return validationErrorf("prerequisite '%s' is the project's own branch group", ownGroup)
+		}
+	}
+
+	return nil
+}
+
+// insertProjectError turns a unique violation on (curriculum_id, identifier)
+// into a validation error; it can only happen when two test projects of the
+// same type are created at the same time.
+func insertProjectError(err error, identifier string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return validationErrorf("project %s already exists in this curriculum", identifier)
+	}
+	return fmt.Errorf("failed to create project: %w", err)
 }
 
 func (s *ProjectService) validatePrerequisites(curriculumID int, prerequisites []string, currentIdentifier string) error {
@@ -102,7 +186,7 @@ func (s *ProjectService) validatePrerequisites(curriculumID int, prerequisites [
 
 	// Get all projects in the curriculum with their identifiers and order
 	query := `
-		SELECT identifier, position_order 
+		SELECT identifier, position_order, project_type, branch_group
 		FROM projects 
 		WHERE curriculum_id = $1
 		ORDER BY position_order
@@ -115,13 +199,19 @@ func (s *ProjectService) validatePrerequisites(curriculumID int, prerequisites [
 	defer rows.Close()
 
 	projectOrders := make(map[string]int)
+	groupOrders := make(map[string][]int)
 	for rows.Next() {
-		var identifier string
-		var order int
-		if err := rows.Scan(&identifier, &order); err != nil {
+		var p models.Project
+		var branchGroup sql.NullInt64
+		if err := rows.Scan(&p.Identifier, &p.PositionOrder, &p.ProjectType, &branchGroup); err != nil {
 			return fmt.Errorf("failed to scan project: %w", err)
 		}
-		projectOrders[identifier] = order
+		applyBranchGroup(&p, branchGroup)
+
+		projectOrders[p.Identifier] = p.PositionOrder
+		if ref := projectGroupReference(p); ref != "" {
+			groupOrders[ref] = append(groupOrders[ref], p.PositionOrder)
+		}
 	}
 
 	// Get current project's order if it exists (for updates)
@@ -139,34 +229,54 @@ func (s *ProjectService) validatePrerequisites(curriculumID int, prerequisites [
 			continue
 		}
 
-		order, exists := projectOrders[prereq]
-		if !exists {
-			return fmt.Errorf("prerequisite '%s' does not exist in this curriculum", prereq)
+		// An exact identifier wins over a branch group of the same name
+		orders := groupOrders[prereq]
+		if order, exists := projectOrders[prereq]; exists {
+			orders = []int{order}
+		}
+		if len(orders) == 0 {
+			return validationErrorf("prerequisite '%s' does not exist in this curriculum", prereq)
 		}
 
 		// For new projects, all prerequisites must have lower order
 		// For existing projects, prerequisites must have order less than current
-		if currentOrder != -1 && order >= currentOrder {
-			return fmt.Errorf("prerequisite '%s' must come before this project", prereq)
+		if currentOrder == -1 {
+			continue
+		}
+		for _, order := range orders {
+			if err := checkPrerequisiteOrder(prereq, order, currentOrder); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
-func (s *ProjectService) CreateProject(curriculumID int, req models.CreateProjectRequest) (*models.Project, error) {
-	// Validate curriculum exists
-	var exists bool
-	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM curricula WHERE id = $1)", curriculumID).Scan(&exists)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check curriculum: %w", err)
+// checkPrerequisiteOrder enforces that a prerequisite is positioned before
+// the project that depends on it.
+func checkPrerequisiteOrder(prereq string, prereqOrder, order int) error {
+	if prereqOrder >= order {
+		return validationErrorf("prerequisite '%s' must come before this project", prereq)
 	}
-	if !exists {
-		return nil, fmt.Errorf("curriculum not found")
+	return nil
+}
+
+func (s *ProjectService) CreateProject(userID, curriculumID int, req models.CreateProjectRequest) (*models.Project, error) {
+	if _, err := requireCurriculumRole(s.db, userID, curriculumID, rolesEdit); err != nil {
+		return nil, err
 	}
 
-	// Generate identifier based on project type
-	identifier, err := s.generateIdentifier(curriculumID, req.ProjectType)
+	// Validate project type
+	if !isValidProjectType(req.ProjectType) {
+		return nil, validationErrorf("invalid project type: %s", req.ProjectType)
+	}
+
+	if err := validateBranchGroup(req.ProjectType, req.BranchGroup, req.Prerequisites); err != nil {
+		return nil, err
+	}
+
+	estimateMin, estimateMax, err := parseEstimate(req.EstimatedTime)
 	if err != nil {
 		return nil, err
 	}
@@ -176,45 +286,338 @@ func (s *ProjectService) CreateProject(curriculumID int, req models.CreateProjec
 		return nil, err
 	}
 
-	// Validate project type
-	if !isValidProjectType(req.ProjectType) {
-		return nil, fmt.Errorf("invalid project type: %s", req.ProjectType)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Generate identifier based on project type
+	identifier, err := generateIdentifier(tx, curriculumID, req.ProjectType, req.BranchGroup)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
-		INSERT INTO projects (curriculum_id, identifier, name, description, learning_objectives, estimated_time, prerequisites, project_type, position_order)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, curriculum_id, identifier, name, description, learning_objectives, estimated_time, prerequisites, project_type, position_order, created_at, updated_at
+		INSERT INTO projects (curriculum_id, identifier, name, description, learning_objectives, estimated_time, estimated_minutes_min, estimated_minutes_max, project_type, branch_group, position_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, curriculum_id, identifier, name, description, learning_objectives, estimated_time, estimated_minutes_min, estimated_minutes_max, project_type, branch_group, position_order, created_at, updated_at
 	`
 
 	var project models.Project
-	err = s.db.QueryRow(query, curriculumID, identifier, req.Name, req.Description,
-		pq.Array(req.LearningObjectives), req.EstimatedTime, pq.Array(req.Prerequisites),
-		req.ProjectType, req.PositionOrder).Scan(
+	var minMinutes, maxMinutes, branchGroup sql.NullInt64
+	err = tx.QueryRow(query, curriculumID, identifier, req.Name, req.Description,
+		pq.Array(req.LearningObjectives), req.EstimatedTime, estimateMin, estimateMax,
+		req.ProjectType, req.BranchGroup, req.PositionOrder).Scan(
 		&project.ID, &project.CurriculumID, &project.Identifier, &project.Name,
 		&project.Description, &project.LearningObjectives, &project.EstimatedTime,
-		&project.Prerequisites, &project.ProjectType, &project.PositionOrder,
+		&minMinutes, &maxMinutes,
+		&project.ProjectType, &branchGroup, &project.PositionOrder,
 		&project.CreatedAt, &project.UpdatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create project: %w", err)
+		return nil, insertProjectError(err, identifier)
+	}
+	applyEstimate(&project, minMinutes, maxMinutes)
+	applyBranchGroup(&project, branchGroup)
+
+	if err := savePrerequisites(tx, &project, req.Prerequisites); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit project: %w", err)
 	}
 
 	return &project, nil
 }
 
+func (s *ProjectService) CreateProjectsBatch(userID, curriculumID int, req models.BatchCreateProjectsRequest) (*models.BatchCreateProjectsResult, error) {
+	if len(req.Projects) == 0 {
+		return nil, validationErrorf("at least one project is required")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the curriculum so concurrent batches validate against a stable
+	// set of existing projects
+	if err := lockCurriculum(tx, userID, curriculumID, rolesEdit); err != nil {
+		return nil, err
+	}
+
+	existing, err := existingProjects(tx, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	drafts := make([]projectDraft, 0, len(req.Projects))
+	for _, item := range req.Projects {
+		drafts = append(drafts, projectDraft{key: strings.TrimSpace(item.Key), req: item.CreateProjectRequest})
+	}
+
+	if err := validateProjectDrafts(drafts, existing); err != nil {
+		return nil, err
+	}
+
+	projectIDs, identifiers, err := insertProjects(tx, curriculumID, drafts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit projects: %w", err)
+	}
+
+	projects, err := s.GetProjectsByCurriculumID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]models.Project, len(projects))
+	for _, p := range projects {
+		byID[p.ID] = p
+	}
+
+	result := &models.BatchCreateProjectsResult{
+		Projects:    make([]models.Project, 0, len(projectIDs)),
+		Identifiers: identifiers,
+	}
+	for _, id := range projectIDs {
+		result.Projects = append(result.Projects, byID[id])
+	}
+
+	return result, nil
+}
+
+// projectDraft is a project that has not been inserted yet. Its
+// prerequisites may reference other drafts by key as well as the identifiers
+// of projects already in the curriculum.
+type projectDraft struct {
+	key string
+	req models.CreateProjectRequest
+}
+
+func (d projectDraft) label(index int) string {
+	if d.key != "" {
+		return d.key
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// existingProjects loads the identifier, type and branch group of every
+// project in a curriculum.
+func existingProjects(q dbtx, curriculumID int) ([]models.Project, error) {
+	rows, err := q.Query(`SELECT identifier, project_type, branch_group FROM projects WHERE curriculum_id = $1`, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make([]models.Project, 0)
+	for rows.Next() {
+		var p models.Project
+		var branchGroup sql.NullInt64
+		if err := rows.Scan(&p.Identifier, &p.ProjectType, &branchGroup); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		applyBranchGroup(&p, branchGroup)
+		existing = append(existing, p)
+	}
+
+	return existing, nil
+}
+
+// validateProjectDrafts checks a set of drafts as a whole against the
+// projects already in the curriculum.
+func validateProjectDrafts(drafts []projectDraft, existing []models.Project) error {
+	typeCounts := make(map[string]int)
+	existingIdentifiers := make(map[string]bool, len(existing))
+	groups := make(map[string]bool)
+	for _, p := range existing {
+		typeCounts[p.ProjectType]++
+		existingIdentifiers[p.Identifier] = true
+		if ref := projectGroupReference(p); ref != "" {
+			groups[ref] = true
+		}
+	}
+	for _, d := range drafts {
+		if ref := projectGroupReference(models.Project{ProjectType: d.req.ProjectType, BranchGroup: d.req.BranchGroup}); ref != "" {
+			groups[ref] = true
+		}
+	}
+
+	keys := make(map[string]bool, len(drafts))
+	for i, d := range drafts {
+		if d.key == "" {
+			continue
+		}
+		if keys[d.key] {
+			return validationErrorf("duplicate project key: %s", d.key)
+		}
+		if existingIdentifiers[d.key] {
+			return validationErrorf("project key %s clashes with an existing identifier", d.label(i))
+		}
+		keys[d.key] = true
+	}
+
+	graphNodes := make([]models.Project, 0, len(drafts))
+	for i, d := range drafts {
+		if d.req.Name == "" || d.req.ProjectType == "" {
+			return validationErrorf("project %s: name and project type are required", d.label(i))
+		}
+		if !isValidProjectType(d.req.ProjectType) {
+			return validationErrorf("project %s: invalid project type: %s", d.label(i), d.req.ProjectType)
+		}
+		if err := validateBranchGroup(d.req.ProjectType, d.req.BranchGroup, d.req.Prerequisites); err != nil {
+			return validationErrorf("project %s: %s", d.label(i), err.Error())
+		}
+		if _, _, err := parseEstimate(d.req.EstimatedTime); err != nil {
+			return validationErrorf("project %s: %s", d.label(i), err.Error())
+		}
+
+		typeCounts[d.req.ProjectType]++
+		if (d.req.ProjectType == models.ProjectTypeRootTest || d.req.ProjectType == models.ProjectTypeBaseTest) &&
+			typeCounts[d.req.ProjectType] > 1 {
+			return validationErrorf("test project of type %s already exists in this curriculum", d.req.ProjectType)
+		}
+
+		draftPrereqs := make(models.StringArray, 0)
+		for _, prereq := range d.req.Prerequisites {
+			prereq = strings.TrimSpace(prereq)
+			if prereq == "" {
+				continue
+			}
+			if keys[prereq] {
+				draftPrereqs = append(draftPrereqs, prereq)
+				continue
+			}
+			if existingIdentifiers[prereq] {
+				continue
+			}
+			if !groups[prereq] {
+				return validationErrorf("prerequisite '%s' of project %s does not exist", prereq, d.label(i))
+			}
+			// A group may have members among the drafts, which can form
+			// a cycle through the group reference
+			draftPrereqs = append(draftPrereqs, prereq)
+		}
+
+		graphNodes = append(graphNodes, models.Project{
+			Identifier:    d.label(i),
+			ProjectType:   d.req.ProjectType,
+			BranchGroup:   d.req.BranchGroup,
+			Prerequisites: draftPrereqs,
+		})
+	}
+
+	// Existing projects cannot depend on drafts, so any cycle lies entirely
+	// within the drafts
+	g := newProjectGraph(graphNodes)
+	if cycles := g.cycles(); len(cycles) > 0 {
+		return validationErrorf("prerequisite cycle between %s", strings.Join(g.identifiers(cycles[0]), ", "))
+	}
+
+	return nil
+}
+
+// insertProjects inserts validated drafts in position order, generating an
+// identifier for each and rewriting draft keys in prerequisites to the
+// generated identifiers. It returns the new project IDs in draft order and a
+// map from draft keys to identifiers.
+func insertProjects(tx *sql.Tx, curriculumID int, drafts []projectDraft) ([]int, map[string]string, error) {
+	order := make([]int, len(drafts))
+	for i := range order {
+		order[i] = i
+	}
+	// Grouped branches go first: an ungrouped branch takes the next number
+	// no group uses, so it has to see every group in the batch, or it can
+	// take the number a later group needs
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := drafts[order[a]].req, drafts[order[b]].req
+		if ua, ub := isUngroupedBranch(ra), isUngroupedBranch(rb); ua != ub {
+			return ub
+		}
+		return ra.PositionOrder < rb.PositionOrder
+	})
+
+	insertQuery := `
+		INSERT INTO projects (curriculum_id, identifier, name, description, learning_objectives, estimated_time, estimated_minutes_min, estimated_minutes_max, project_type, branch_group, position_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+
+	identifiers := make(map[string]string, len(drafts))
+	projectIDs := make([]int, len(drafts))
+	for _, i := range order {
+		req := drafts[i].req
+
+		estimateMin, estimateMax, err := parseEstimate(req.EstimatedTime)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		identifier, err := generateIdentifier(tx, curriculumID, req.ProjectType, req.BranchGroup)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = tx.QueryRow(insertQuery, curriculumID, identifier, req.Name, req.Description,
+			pq.Array(req.LearningObjectives), req.EstimatedTime, estimateMin, estimateMax,
+			req.ProjectType, req.BranchGroup, req.PositionOrder).Scan(&projectIDs[i])
+		if err != nil {
+			return nil, nil, insertProjectError(err, identifier)
+		}
+
+		if drafts[i].key != "" {
+			identifiers[drafts[i].key] = identifier
+		}
+	}
+
+	// Prerequisites are written once every identifier is known, so drafts
+	// do not need to be listed after their prerequisites
+	ids, err := curriculumProjectIDs(tx, curriculumID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, d := range drafts {
+		if len(d.req.Prerequisites) == 0 {
+			continue
+		}
+
+		prerequisites := make([]string, 0, len(d.req.Prerequisites))
+		for _, prereq := range d.req.Prerequisites {
+			prereq = strings.TrimSpace(prereq)
+			if identifier, isDraft := identifiers[prereq]; isDraft {
+				prereq = identifier
+			}
+			prerequisites = append(prerequisites, prereq)
+		}
+
+		if err := setPrerequisites(tx, projectIDs[i], prerequisites, ids); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return projectIDs, identifiers, nil
+}
+
 func (s *ProjectService) GetProjectsByCurriculumID(userID, curriculumID int) ([]models.Project, error) {
 	query := `
 		SELECT 
 			p.id, p.curriculum_id, p.identifier, p.name, p.description, 
-			p.learning_objectives, p.estimated_time, p.prerequisites, 
-			p.project_type, p.position_order, p.created_at, p.updated_at,
+			p.learning_objectives, p.estimated_time, p.estimated_minutes_min, p.estimated_minutes_max,
+			p.project_type, p.branch_group, p.position_order, p.created_at, p.updated_at,
 			pr.id, pr.user_id, pr.project_id, pr.status, pr.completion_percentage,
 			pr.started_at, pr.completed_at, pr.created_at, pr.updated_at
 		FROM projects p
-		INNER JOIN curricula c ON p.curriculum_id = c.id
+		INNER JOIN curriculum_members m ON m.curriculum_id = p.curriculum_id AND m.user_id = $1
 		LEFT JOIN progress pr ON p.id = pr.project_id AND pr.user_id = $1
-		WHERE p.curriculum_id = $2 AND c.user_id = $1
+		WHERE p.curriculum_id = $2
 		ORDER BY p.position_order, p.created_at
 	`
 
@@ -227,14 +630,15 @@ func (s *ProjectService) GetProjectsByCurriculumID(userID, curriculumID int) ([]
 	projects := make([]models.Project, 0)
 	for rows.Next() {
 		var p models.Project
+		var minMinutes, maxMinutes, branchGroup sql.NullInt64
 		var progressID, progressUserID, progressProjectID, progressCompletionPercentage sql.NullInt64
 		var progressStatus sql.NullString
 		var progressStartedAt, progressCompletedAt, progressCreatedAt, progressUpdatedAt sql.NullTime
 
 		err := rows.Scan(
 			&p.ID, &p.CurriculumID, &p.Identifier, &p.Name, &p.Description,
-			&p.LearningObjectives, &p.EstimatedTime, &p.Prerequisites,
-			&p.ProjectType, &p.PositionOrder, &p.CreatedAt, &p.UpdatedAt,
+			&p.LearningObjectives, &p.EstimatedTime, &minMinutes, &maxMinutes,
+			&p.ProjectType, &branchGroup, &p.PositionOrder, &p.CreatedAt, &p.UpdatedAt,
 			&progressID, &progressUserID, &progressProjectID, &progressStatus,
 			&progressCompletionPercentage, &progressStartedAt, &progressCompletedAt,
 			&progressCreatedAt, &progressUpdatedAt,
@@ -242,6 +646,8 @@ func (s *ProjectService) GetProjectsByCurriculumID(userID, curriculumID int) ([]
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
+		applyEstimate(&p, minMinutes, maxMinutes)
+		applyBranchGroup(&p, branchGroup)
 
 		if progressID.Valid {
 			progress := models.Progress{
@@ -260,6 +666,20 @@ func (s *ProjectService) GetProjectsByCurriculumID(userID, curriculumID int) ([]
 
 		projects = append(projects, p)
 	}
+	rows.Close()
+
+	projectIDs := make([]int, 0, len(projects))
+	for _, p := range projects {
+		projectIDs = append(projectIDs, p.ID)
+	}
+
+	prerequisites, err := loadPrerequisites(s.db, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range projects {
+		projects[i].Prerequisites = prerequisites[projects[i].ID]
+	}
 
 	return projects, nil
 }
@@ -268,26 +688,36 @@ func (s *ProjectService) GetProjectByID(userID, projectID int) (*models.Project,
 	query := `
 		SELECT 
 			p.id, p.curriculum_id, p.identifier, p.name, p.description, 
-			p.learning_objectives, p.estimated_time, p.prerequisites, 
-			p.project_type, p.position_order, p.created_at, p.updated_at
+			p.learning_objectives, p.estimated_time, p.estimated_minutes_min, p.estimated_minutes_max,
+			p.project_type, p.branch_group, p.position_order, p.created_at, p.updated_at
 		FROM projects p
-		INNER JOIN curricula c ON p.curriculum_id = c.id
-		WHERE p.id = $1 AND c.user_id = $2
+		INNER JOIN curriculum_members m ON m.curriculum_id = p.curriculum_id AND m.user_id = $2
+		WHERE p.id = $1
 	`
 
 	var project models.Project
+	var minMinutes, maxMinutes, branchGroup sql.NullInt64
 	err := s.db.QueryRow(query, projectID, userID).Scan(
 		&project.ID, &project.CurriculumID, &project.Identifier, &project.Name,
 		&project.Description, &project.LearningObjectives, &project.EstimatedTime,
-		&project.Prerequisites, &project.ProjectType, &project.PositionOrder,
+		&minMinutes, &maxMinutes,
+		&project.ProjectType, &branchGroup, &project.PositionOrder,
 		&project.CreatedAt, &project.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("project not found")
+			return nil, ErrProjectNotFound
 		}
 		return nil, fmt.Errorf("failed to query project: %w", err)
 	}
+	applyEstimate(&project, minMinutes, maxMinutes)
+	applyBranchGroup(&project, branchGroup)
+
+	prerequisites, err := loadPrerequisites(s.db, []int{project.ID})
+	if err != nil {
+		return nil, err
+	}
+	project.Prerequisites = prerequisites[project.ID]
 
 	return &project, nil
 }
@@ -299,6 +729,10 @@ func (s *ProjectService) UpdateProject(userID, projectID int, req models.UpdateP
 		return nil, err
 	}
 
+	if _, err := requireCurriculumRole(s.db, userID, currentProject.CurriculumID, rolesEdit); err != nil {
+		return nil, err
+	}
+
 	// Validate prerequisites
 	if err := s.validatePrerequisites(currentProject.CurriculumID, req.Prerequisites, currentProject.Identifier); err != nil {
 		return nil, err
@@ -306,81 +740,333 @@ func (s *ProjectService) UpdateProject(userID, projectID int, req models.UpdateP
 
 	// Validate project type
 	if !isValidProjectType(req.ProjectType) {
-		return nil, fmt.Errorf("invalid project type: %s", req.ProjectType)
+		return nil, validationErrorf("invalid project type: %s", req.ProjectType)
+	}
+
+	estimateMin, estimateMax, err := parseEstimate(req.EstimatedTime)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Changing the type gives the project a new identifier for its new type.
+	// Dependents reference it by ID, so their prerequisites follow the rename.
+	identifier := currentProject.Identifier
+	branchGroup := currentProject.BranchGroup
+	if req.ProjectType != currentProject.ProjectType {
+		if _, isBranch := branchPrefixes[req.ProjectType]; !isBranch {
+			branchGroup = nil
+		}
+
+		identifier, err = generateIdentifier(tx, currentProject.CurriculumID, req.ProjectType, branchGroup)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	query := `
 		UPDATE projects
-		SET name = $1, description = $2, learning_objectives = $3,
-		    estimated_time = $4, prerequisites = $5, project_type = $6, position_order = $7,
+		SET identifier = $1, name = $2, description = $3, learning_objectives = $4,
+		    estimated_time = $5, estimated_minutes_min = $6, estimated_minutes_max = $7,
+		    project_type = $8, branch_group = $9, position_order = $10,
 		    updated_at = CURRENT_TIMESTAMP
-		FROM curricula c
-		WHERE projects.id = $8 AND projects.curriculum_id = c.id AND c.user_id = $9
+		WHERE projects.id = $11
 		RETURNING projects.id, projects.curriculum_id, projects.identifier, projects.name, 
 		         projects.description, projects.learning_objectives, projects.estimated_time, 
-		         projects.prerequisites, projects.project_type, projects.position_order, 
+		         projects.estimated_minutes_min, projects.estimated_minutes_max,
+		         projects.project_type, projects.branch_group, projects.position_order, 
 		         projects.created_at, projects.updated_at
 	`
 
 	var project models.Project
-	err = s.db.QueryRow(query, req.Name, req.Description,
-		pq.Array(req.LearningObjectives), req.EstimatedTime, pq.Array(req.Prerequisites),
-		req.ProjectType, req.PositionOrder, projectID, userID).Scan(
+	var minMinutes, maxMinutes, updatedBranchGroup sql.NullInt64
+	err = tx.QueryRow(query, identifier, req.Name, req.Description,
+		pq.Array(req.LearningObjectives), req.EstimatedTime, estimateMin, estimateMax,
+		req.ProjectType, branchGroup, req.PositionOrder, projectID).Scan(
 		&project.ID, &project.CurriculumID, &project.Identifier, &project.Name,
 		&project.Description, &project.LearningObjectives, &project.EstimatedTime,
-		&project.Prerequisites, &project.ProjectType, &project.PositionOrder,
+		&minMinutes, &maxMinutes,
+		&project.ProjectType, &updatedBranchGroup, &project.PositionOrder,
 		&project.CreatedAt, &project.UpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("project not found")
+			return nil, ErrProjectNotFound
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, validationErrorf("project %s already exists in this curriculum", identifier)
 		}
 		return nil, fmt.Errorf("failed to update project: %w", err)
 	}
+	applyEstimate(&project, minMinutes, maxMinutes)
+	applyBranchGroup(&project, updatedBranchGroup)
+
+	if err := savePrerequisites(tx, &project, req.Prerequisites); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit project: %w", err)
+	}
 
 	return &project, nil
 }
 
 func (s *ProjectService) DeleteProject(userID, projectID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := requireProjectRole(tx, userID, projectID, rolesEdit); err != nil {
+		return err
+	}
+
+	// Lock the project so no new dependents can reference it between the
+	// check and the delete
+	var lockedID int
+	err = tx.QueryRow(`SELECT id FROM projects WHERE id = $1 FOR UPDATE`, projectID).Scan(&lockedID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrProjectNotFound
+		}
+		return fmt.Errorf("failed to query project: %w", err)
+	}
+
 	// Check if any other projects depend on this one
-	var dependentCount int
-	checkQuery := `
-		SELECT COUNT(*)
-		FROM projects p
-		INNER JOIN curricula c ON p.curriculum_id = c.id
-		WHERE c.user_id = $1 AND $2 = ANY(p.prerequisites)
-	`
+	dependents, err := dependentProjects(tx, projectID)
+	if err != nil {
+		return err
+	}
+
+	if len(dependents) > 0 {
+		return &DependentProjectsError{Dependents: dependents}
+	}
 
-	err := s.db.QueryRow(checkQuery, userID, projectID).Scan(&dependentCount)
+	if _, err := tx.Exec(`DELETE FROM projects WHERE id = $1`, projectID); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit delete: %w", err)
+	}
+
+	return nil
+}
+
+// ReorderProjects rewrites the position_order of every project in a
+// curriculum to follow the given list of project IDs, which must contain each
+// project exactly once. Orderings that put a project before one of its
+// prerequisites are rejected.
+func (s *ProjectService) ReorderProjects(userID, curriculumID int, req models.ReorderProjectsRequest) ([]models.Project, error) {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to check dependencies: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	if dependentCount > 0 {
-		return fmt.Errorf("cannot delete project: %d other projects depend on it", dependentCount)
+	// Lock the curriculum so projects cannot be added or reordered while the
+	// new order is validated
+	if err := lockCurriculum(tx, userID, curriculumID, rolesEdit); err != nil {
+		return nil, err
 	}
 
-	query := `
-		DELETE FROM projects
-		USING curricula c
-		WHERE projects.id = $1 AND projects.curriculum_id = c.id AND c.user_id = $2
-	`
+	rows, err := tx.Query(`SELECT id, identifier, project_type, branch_group FROM projects WHERE curriculum_id = $1`, curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[int]models.Project)
+	for rows.Next() {
+		var p models.Project
+		var branchGroup sql.NullInt64
+		if err := rows.Scan(&p.ID, &p.Identifier, &p.ProjectType, &branchGroup); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		applyBranchGroup(&p, branchGroup)
+		byID[p.ID] = p
+	}
+	rows.Close()
 
-	result, err := s.db.Exec(query, projectID, userID)
+	// Arrange the projects in their new order, so a project's index is its
+	// new position
+	ordered := make([]models.Project, 0, len(req.ProjectIDs))
+	seen := make(map[int]bool, len(req.ProjectIDs))
+	for _, id := range req.ProjectIDs {
+		p, exists := byID[id]
+		if !exists {
+			return nil, validationErrorf("project %d does not belong to this curriculum", id)
+		}
+		if seen[id] {
+			return nil, validationErrorf("project %d is listed more than once", id)
+		}
+		seen[id] = true
+		ordered = append(ordered, p)
+	}
+	if len(ordered) != len(byID) {
+		return nil, validationErrorf("every project in the curriculum must be listed: expected %d, got %d", len(byID), len(ordered))
+	}
+
+	prerequisites, err := loadPrerequisites(tx, req.ProjectIDs)
 	if err != nil {
-		return fmt.Errorf("failed to delete project: %w", err)
+		return nil, err
+	}
+	for i := range ordered {
+		ordered[i].Prerequisites = prerequisites[ordered[i].ID]
+	}
+
+	g := newProjectGraph(ordered)
+	for i, p := range ordered {
+		for _, j := range g.prereqs[i] {
+			if err := checkPrerequisiteOrder(ordered[j].Identifier, j, i); err != nil {
+				return nil, validationErrorf("project %s: %s", p.Identifier, err.Error())
+			}
+		}
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	_, err = tx.Exec(`
+		UPDATE projects
+		SET position_order = o.position, updated_at = CURRENT_TIMESTAMP
+		FROM unnest($1::integer[]) WITH ORDINALITY AS o(project_id, position)
+		WHERE projects.id = o.project_id AND projects.position_order != o.position
+	`, pq.Array(req.ProjectIDs))
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to reorder projects: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("project not found")
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reorder: %w", err)
 	}
 
-	return nil
+	return s.GetProjectsByCurriculumID(userID, curriculumID)
+}
+
+func (s *ProjectService) GetCurriculumGraph(userID, curriculumID int) (*models.CurriculumGraph, error) {
+	projects, err := s.GetProjectsByCurriculumID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newProjectGraph(projects)
+
+	graph := &models.CurriculumGraph{
+		CurriculumID:       curriculumID,
+		Nodes:              make([]models.GraphNode, 0, len(projects)),
+		Edges:              make([]models.GraphEdge, 0),
+		Groups:             make([]models.GraphGroup, 0, len(g.groupRefs)),
+		Layers:             make([][]string, 0),
+		Cycles:             make([][]string, 0),
+		DanglingReferences: make([]models.DanglingReference, 0),
+	}
+
+	for i, p := range projects {
+		graph.Nodes = append(graph.Nodes, models.GraphNode{
+			ProjectID:     p.ID,
+			Identifier:    p.Identifier,
+			Name:          p.Name,
+			ProjectType:   p.ProjectType,
+			BranchGroup:   p.BranchGroup,
+			PositionOrder: p.PositionOrder,
+			Status:        g.status(i),
+		})
+
+		for _, prereq := range g.prereqs[i] {
+			graph.Edges = append(graph.Edges, models.GraphEdge{
+				From: projects[prereq].Identifier,
+				To:   p.Identifier,
+			})
+		}
+
+		for _, missing := range g.dangling[i] {
+			graph.DanglingReferences = append(graph.DanglingReferences, models.DanglingReference{
+				Identifier:   p.Identifier,
+				Prerequisite: missing,
+			})
+		}
+	}
+
+	for _, ref := range g.groupRefs {
+		members := g.groups[ref]
+		first := projects[members[0]]
+		graph.Groups = append(graph.Groups, models.GraphGroup{
+			Reference:   ref,
+			ProjectType: first.ProjectType,
+			BranchGroup: *first.BranchGroup,
+			Members:     g.identifiers(members),
+		})
+	}
+
+	layers, unplaced := g.layers()
+	for _, layer := range layers {
+		graph.Layers = append(graph.Layers, g.identifiers(layer))
+	}
+	graph.Unreachable = g.identifiers(unplaced)
+
+	for _, cycle := range g.cycles() {
+		graph.Cycles = append(graph.Cycles, g.identifiers(cycle))
+	}
+
+	return graph, nil
+}
+
+func (s *ProjectService) GetNextProjects(userID, curriculumID int) ([]models.NextProject, error) {
+	projects, err := s.GetProjectsByCurriculumID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newProjectGraph(projects)
+
+	next := make([]models.NextProject, 0)
+	for i, p := range projects {
+		if !g.available(i, -1) {
+			continue
+		}
+
+		unlocks := 0
+		for _, d := range g.dependents[i] {
+			if g.available(d, i) {
+				unlocks++
+			}
+		}
+
+		downstream := 0
+		for _, d := range g.downstream(i) {
+			if !g.completed(d) {
+				downstream++
+			}
+		}
+
+		next = append(next, models.NextProject{
+			Project:         p,
+			Unlocks:         unlocks,
+			DownstreamCount: downstream,
+		})
+	}
+
+	sort.SliceStable(next, func(a, b int) bool {
+		pa, pb := next[a], next[b]
+		if pa.Project.PositionOrder != pb.Project.PositionOrder {
+			return pa.Project.PositionOrder < pb.Project.PositionOrder
+		}
+		if ta, tb := projectTypeTiers[pa.Project.ProjectType], projectTypeTiers[pb.Project.ProjectType]; ta != tb {
+			return ta < tb
+		}
+		if pa.Unlocks != pb.Unlocks {
+			return pa.Unlocks > pb.Unlocks
+		}
+		return pa.DownstreamCount > pb.DownstreamCount
+	})
+
+	return next, nil
 }
 
 func isValidProjectType(projectType string) bool {
@@ -396,3 +1082,59 @@ func isValidProjectType(projectType string) bool {
 	}
 	return validTypes[projectType]
 }
+
+func parseEstimate(text string) (sql.NullInt64, sql.NullInt64, error) {
+	if strings.TrimSpace(text) == "" {
+		return sql.NullInt64{}, sql.NullInt64{}, nil
+	}
+
+	minMinutes, maxMinutes, err := utils.ParseEstimatedTime(text)
+	if err != nil {
+		return sql.NullInt64{}, sql.NullInt64{}, &ValidationError{Message: err.Error()}
+	}
+
+	return sql.NullInt64{Int64: int64(minMinutes), Valid: true},
+		sql.NullInt64{Int64: int64(maxMinutes), Valid: true}, nil
+}
+
+func applyEstimate(project *models.Project, minMinutes, maxMinutes sql.NullInt64) {
+	if !minMinutes.Valid || !maxMinutes.Valid {
+		return
+	}
+
+	project.Estimate = &models.Estimate{
+		Text:       project.EstimatedTime,
+		MinMinutes: int(minMinutes.Int64),
+		MaxMinutes: int(maxMinutes.Int64),
+	}
+}
+
+func applyBranchGroup(project *models.Project, branchGroup sql.NullInt64) {
+	if !branchGroup.Valid {
+		return
+	}
+
+	group := int(branchGroup.Int64)
+	project.BranchGroup = &group
+}
+
+// savePrerequisites replaces the prerequisites of a project in its curriculum
+// and reloads them onto the project as identifiers.
+func savePrerequisites(q dbtx, project *models.Project, refs []string) error {
+	ids, err := curriculumProjectIDs(q, project.CurriculumID)
+	if err != nil {
+		return err
+	}
+
+	if err := setPrerequisites(q, project.ID, refs, ids); err != nil {
+		return err
+	}
+
+	prerequisites, err := loadPrerequisites(q, []int{project.ID})
+	if err != nil {
+		return err
+	}
+	project.Prerequisites = prerequisites[project.ID]
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"curriculum-tracker/internal/testdb"
+	"curriculum-tracker/models"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// createTestCurriculum creates a user and a curriculum they own.
+func createTestCurriculum(t *testing.T, db *sql.DB) (*models.User, *models.Curriculum) {
+	t.Helper()
+
+	user, err := NewAuthService(db).CreateUser(models.CreateUserRequest{
+		Email:    testdb.Email(),
+		Password: "password123",
+		Name:     "Test User",
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	curriculum, err := NewCurriculumService(db).CreateCurriculum(user.ID, models.CreateCurriculumRequest{Name: "Test Curriculum"})
+	if err != nil {
+		t.Fatalf("failed to create curriculum: %v", err)
+	}
+
+	return user, curriculum
+}
+
+func TestCreateProjectConcurrentIdentifiers(t *testing.T) {
+	db := testdb.Open(t)
+	user, curriculum := createTestCurriculum(t, db)
+	projects := NewProjectService(db)
+
+	const creates = 40
+	projectTypes := []string{models.ProjectTypeBase, models.ProjectTypeLowerBranch}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, creates*len(projectTypes))
+	identifiers := make(chan string, creates*len(projectTypes))
+	for i := 0; i < creates; i++ {
+		for _, projectType := range projectTypes {
+			wg.Add(1)
+			go func(i int, projectType string) {
+				defer wg.Done()
+				project, err := projects.CreateProject(user.ID, curriculum.ID, models.CreateProjectRequest{
+					Name:        fmt.Sprintf("%s %d", projectType, i),
+					ProjectType: projectType,
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+				identifiers <- project.Identifier
+			}(i, projectType)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	close(identifiers)
+
+	for err := range errs {
+		t.Errorf("CreateProject failed: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for identifier := range identifiers {
+		if seen[identifier] {
+			t.Errorf("identifier %s was allocated twice", identifier)
+		}
+		seen[identifier] = true
+	}
+
+	for _, prefix := range []string{"B", "LB"} {
+		for n := 1; n <= creates; n++ {
+			if identifier := fmt.Sprintf("%s%d", prefix, n); !seen[identifier] {
+				t.Errorf("identifier %s was skipped", identifier)
+			}
+		}
+	}
+
+	var duplicates int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT identifier FROM projects WHERE curriculum_id = $1 GROUP BY identifier HAVING COUNT(*) > 1
+		) d
+	`, curriculum.ID).Scan(&duplicates)
+	if err != nil {
+		t.Fatalf("failed to count duplicates: %v", err)
+	}
+	if duplicates > 0 {
+		t.Errorf("found %d duplicated identifiers in the database", duplicates)
+	}
+}
+
+func TestCreateProjectDoesNotReuseDeletedIdentifiers(t *testing.T) {
+	db := testdb.Open(t)
+	user, curriculum := createTestCurriculum(t, db)
+	projects := NewProjectService(db)
+
+	create := func() *models.Project {
+		t.Helper()
+		project, err := projects.CreateProject(user.ID, curriculum.ID, models.CreateProjectRequest{
+			Name:        "Base",
+			ProjectType: models.ProjectTypeBase,
+		})
+		if err != nil {
+			t.Fatalf("CreateProject failed: %v", err)
+		}
+		return project
+	}
+
+	create()
+	second := create()
+	create()
+
+	if _, err := db.Exec(`DELETE FROM projects WHERE id = $1`, second.ID); err != nil {
+		t.Fatalf("failed to delete project: %v", err)
+	}
+
+	if next := create(); next.Identifier != "B4" {
+		t.Errorf("next identifier = %s, want B4", next.Identifier)
+	}
+}
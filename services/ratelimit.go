@@ -0,0 +1,90 @@
+package services
+
+import (
+	"curriculum-tracker/utils"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitService keeps rate limit token buckets in Postgres so that every
+// instance of the API shares them. It satisfies middleware.RateLimitStore.
+type RateLimitService struct {
+	db  *sql.DB
+	now func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewRateLimitService(db *sql.DB, now func() time.Time) *RateLimitService {
+	return &RateLimitService{db: db, now: now}
+}
+
+func (s *RateLimitService) Take(key string, limit utils.RateLimit) (float64, bool, error) {
+	now := s.now().UTC()
+
+	if err := s.sweep(now); err != nil {
+		return 0, false, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO rate_limit_buckets (bucket_key, tokens, updated_at, full_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (bucket_key) DO NOTHING
+	`, key, limit.Burst, now)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create rate limit bucket: %w", err)
+	}
+
+	var tokens float64
+	var updatedAt time.Time
+	err = tx.QueryRow(`
+		SELECT tokens, updated_at FROM rate_limit_buckets WHERE bucket_key = $1 FOR UPDATE
+	`, key).Scan(&tokens, &updatedAt)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to query rate limit bucket: %w", err)
+	}
+
+	tokens, allowed := limit.Take(tokens, now.Sub(asUTC(updatedAt)))
+	fullAt := now.Add(limit.Until(tokens, float64(limit.Burst)))
+
+	_, err = tx.Exec(`
+		UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2, full_at = $3
+		WHERE bucket_key = $4
+	`, tokens, now, fullAt, key)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to update rate limit bucket: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit rate limit bucket: %w", err)
+	}
+
+	return tokens, allowed, nil
+}
+
+// sweep deletes full buckets, which are the same as no bucket, at most once
+// a minute.
+func (s *RateLimitService) sweep(now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < time.Minute {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	if _, err := s.db.Exec(`DELETE FROM rate_limit_buckets WHERE full_at <= $1`, now); err != nil {
+		return fmt.Errorf("failed to clear rate limit buckets: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"curriculum-tracker/utils"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// A session is one sign-in on one device. It holds a long-lived refresh
+// token, stored hashed, that is swapped for a new one on every refresh;
+// access tokens name their session in the sid claim, so revoking a session
+// also rejects the access tokens issued for it.
+type SessionService struct {
+	db *sql.DB
+}
+
+func NewSessionService(db *sql.DB) *SessionService {
+	return &SessionService{db: db}
+}
+
+// CreateSession starts a session for a user and returns it together with
+// its refresh token. The token is only ever available here and in
+// RotateSession; the database keeps its hash.
+func (s *SessionService) CreateSession(userID int, client models.SessionClient, duration time.Duration) (*models.Session, string, error) {
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	// Expired sessions are no use to anyone; clear them out while we are here
+	if _, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = $1 AND expires_at < CURRENT_TIMESTAMP`, userID); err != nil {
+		return nil, "", fmt.Errorf("failed to clear expired sessions: %w", err)
+	}
+
+	query := `
+		INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + $5 * INTERVAL '1 second')
+		RETURNING id, user_id, user_agent, ip_address, expires_at, created_at, last_used_at
+	`
+
+	var session models.Session
+	err = s.db.QueryRow(query, userID, utils.HashToken(refreshToken), client.UserAgent, client.IPAddress,
+		int(duration.Seconds())).Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+		&session.ExpiresAt, &session.CreatedAt, &session.LastUsedAt,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	session.Current = true
+
+	return &session, refreshToken, nil
+}
+
+// RotateSession exchanges a refresh token for a new one and extends the
+// session, recording the device it was refreshed from. Presenting a refresh
+// token that was already rotated out means it leaked, so the whole session
+// is revoked.
+func (s *SessionService) RotateSession(refreshToken string, client models.SessionClient, duration time.Duration) (*models.Session, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hash := utils.HashToken(refreshToken)
+
+	var sessionID int
+	var current, active bool
+	err = tx.QueryRow(`
+		SELECT id, refresh_token_hash = $1, revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		FROM sessions
+		WHERE refresh_token_hash = $1 OR previous_token_hash = $1
+		FOR UPDATE
+	`, hash).Scan(&sessionID, &current, &active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", ErrInvalidRefreshToken
+		}
+		return nil, "", fmt.Errorf("failed to query session: %w", err)
+	}
+
+	if !active {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if !current {
+		if _, err := tx.Exec(`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1`, sessionID); err != nil {
+			return nil, "", fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, "", fmt.Errorf("failed to commit session: %w", err)
+		}
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	newToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	query := `
+		UPDATE sessions
+		SET refresh_token_hash = $1, previous_token_hash = refresh_token_hash,
+		    user_agent = $2, ip_address = $3,
+		    expires_at = CURRENT_TIMESTAMP + $4 * INTERVAL '1 second', last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING id, user_id, user_agent, ip_address, expires_at, created_at, last_used_at
+	`
+
+	var session models.Session
+	err = tx.QueryRow(query, utils.HashToken(newToken), client.UserAgent, client.IPAddress,
+		int(duration.Seconds()), sessionID).Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+		&session.ExpiresAt, &session.CreatedAt, &session.LastUsedAt,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to rotate session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, "", fmt.Errorf("failed to commit session: %w", err)
+	}
+	session.Current = true
+
+	return &session, newToken, nil
+}
+
+// ListSessions returns the user's active sessions, most recently used
+// first, marking the one identified by currentSessionID.
+func (s *SessionService) ListSessions(userID, currentSessionID int) ([]models.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip_address, expires_at, created_at, last_used_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY last_used_at DESC, id DESC
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := make([]models.Session, 0)
+	for rows.Next() {
+		var session models.Session
+		err := rows.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+			&session.ExpiresAt, &session.CreatedAt, &session.LastUsedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		session.Current = session.ID == currentSessionID
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions, returning
+// ErrSessionNotFound if it is not an active session of theirs. When the
+// access token used for the request belongs to it, pass the token's ID and
+// expiry to revoke the token too; the revocation is forgotten once the token
+// would have expired anyway.
+func (s *SessionService) RevokeSession(userID, sessionID int, tokenID string, expiresAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	if err := revokeToken(tx, tokenID, expiresAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions ends every session of a user, signing them out on all
+// devices, and revokes the access token used for the request.
+func (s *SessionService) RevokeAllSessions(userID int, tokenID string, expiresAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := revokeUserSessions(tx, userID, 0); err != nil {
+		return err
+	}
+
+	if err := revokeToken(tx, tokenID, expiresAt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sessions: %w", err)
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether an access token was revoked, either directly or
+// because its session was. It satisfies middleware.TokenStore.
+func (s *SessionService) IsRevoked(tokenID string, sessionID int) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+		    OR NOT EXISTS (
+		        SELECT 1 FROM sessions
+		        WHERE id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		    )
+	`
+
+	var revoked bool
+	if err := s.db.QueryRow(query, tokenID, sessionID).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// revokeUserSessions ends every active session of a user except
+// keepSessionID; pass 0 to end them all.
+func revokeUserSessions(q dbtx, userID, keepSessionID int) error {
+	_, err := q.Exec(`
+		UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+	`, userID, keepSessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+func revokeToken(q dbtx, tokenID string, expiresAt time.Time) error {
+	if tokenID == "" {
+		return nil
+	}
+
+	// Revocations only matter until the token expires on its own
+	if _, err := q.Exec(`DELETE FROM revoked_tokens WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+		return fmt.Errorf("failed to clear revoked tokens: %w", err)
+	}
+
+	remaining := int(time.Until(expiresAt).Seconds()) + 1
+	if remaining <= 0 {
+		return nil
+	}
+
+	_, err := q.Exec(`
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, CURRENT_TIMESTAMP + $2 * INTERVAL '1 second')
+		ON CONFLICT (jti) DO NOTHING
+	`, tokenID, remaining)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
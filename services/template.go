@@ -0,0 +1,188 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+func (s *CurriculumService) GetTemplates() ([]models.CurriculumTemplate, error) {
+	query := `
+		SELECT c.id, c.name, c.description, u.id, u.name, COUNT(p.id), c.created_at, c.updated_at
+		FROM curricula c
+		JOIN users u ON c.user_id = u.id
+		LEFT JOIN projects p ON p.curriculum_id = c.id
+		WHERE c.is_template
+		GROUP BY c.id, c.name, c.description, u.id, u.name, c.created_at, c.updated_at
+		ORDER BY c.name, c.id
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := make([]models.CurriculumTemplate, 0)
+	for rows.Next() {
+		var t models.CurriculumTemplate
+		err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.OwnerID, &t.OwnerName,
+			&t.TotalProjects, &t.CreatedAt, &t.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, nil
+}
+
+// CloneCurriculum deep-copies a curriculum with its projects, identifiers,
+// objectives and prerequisites, but none of the progress, notes or time
+// entries recorded against it. Anyone may clone a template into their own
+// account; cloning anything else requires being an owner or editor of the
+// source. Cloning into another account requires owning the source or being
+// a mentor or admin, and does not reveal whether the target email exists.
+func (s *CurriculumService) CloneCurriculum(userID, curriculumID int, req models.CloneCurriculumRequest) (*models.Curriculum, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source models.Curriculum
+	err = tx.QueryRow(`
+		SELECT c.id, c.user_id, c.name, c.description, c.is_template, COALESCE(m.role, '')
+		FROM curricula c
+		LEFT JOIN curriculum_members m ON m.curriculum_id = c.id AND m.user_id = $2
+		WHERE c.id = $1 AND (m.role IS NOT NULL OR c.is_template)
+	`, curriculumID, userID).Scan(&source.ID, &source.UserID, &source.Name, &source.Description, &source.IsTemplate, &source.Role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCurriculumNotFound
+		}
+		return nil, fmt.Errorf("failed to query curriculum: %w", err)
+	}
+
+	canEdit := source.Role == models.MemberRoleOwner || source.Role == models.MemberRoleEditor
+	if !canEdit && !source.IsTemplate {
+		return nil, ErrInsufficientRole
+	}
+
+	targetUserID := userID
+	if email := strings.TrimSpace(req.TargetEmail); email != "" {
+		var userRole string
+		if err := tx.QueryRow(`SELECT role FROM users WHERE id = $1`, userID).Scan(&userRole); err != nil {
+			return nil, fmt.Errorf("failed to query user: %w", err)
+		}
+		canAssign := source.Role == models.MemberRoleOwner ||
+			userRole == models.UserRoleMentor || userRole == models.UserRoleAdmin
+		if !canAssign {
+			return nil, ErrInsufficientRole
+		}
+
+		err := tx.QueryRow(`SELECT id FROM users WHERE email = $1`, email).Scan(&targetUserID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errAccountUnavailable
+			}
+			return nil, fmt.Errorf("failed to query user: %w", err)
+		}
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = source.Name
+	}
+
+	var cloneID int
+	err = tx.QueryRow(
+		`INSERT INTO curricula (user_id, name, description) VALUES ($1, $2, $3) RETURNING id`,
+		targetUserID, name, source.Description,
+	).Scan(&cloneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create curriculum: %w", err)
+	}
+
+	if err := addCurriculumMember(tx, cloneID, targetUserID, models.MemberRoleOwner); err != nil {
+		return nil, err
+	}
+
+	if err := cloneProjects(tx, curriculumID, cloneID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit clone: %w", err)
+	}
+
+	clone, err := s.GetCurriculumByID(targetUserID, cloneID)
+	if err != nil {
+		return nil, err
+	}
+
+	clone.Projects, err = s.projectService.GetProjectsByCurriculumID(targetUserID, cloneID)
+	if err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
+
+// cloneProjects copies every project of one curriculum into another,
+// keeping identifiers so prerequisites can be matched up by identifier. The
+// identifier counters are copied too, so numbers skipped in the source are
+// not handed out again in the clone.
+func cloneProjects(tx *sql.Tx, sourceID, targetID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO projects (curriculum_id, identifier, name, description, learning_objectives, estimated_time,
+			estimated_minutes_min, estimated_minutes_max, project_type, branch_group, position_order)
+		SELECT $2, identifier, name, description, learning_objectives, estimated_time,
+			estimated_minutes_min, estimated_minutes_max, project_type, branch_group, position_order
+		FROM projects
+		WHERE curriculum_id = $1
+		ORDER BY position_order, id
+	`, sourceID, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to copy projects: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO project_prerequisites (project_id, prerequisite_id)
+		SELECT target.id, target_prereq.id
+		FROM project_prerequisites pp
+		JOIN projects source ON source.id = pp.project_id
+		JOIN projects source_prereq ON source_prereq.id = pp.prerequisite_id
+		JOIN projects target ON target.curriculum_id = $2 AND target.identifier = source.identifier
+		JOIN projects target_prereq ON target_prereq.curriculum_id = $2 AND target_prereq.identifier = source_prereq.identifier
+		WHERE source.curriculum_id = $1
+	`, sourceID, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to copy prerequisites: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO project_prerequisite_groups (project_id, project_type, branch_group)
+		SELECT target.id, pg.project_type, pg.branch_group
+		FROM project_prerequisite_groups pg
+		JOIN projects source ON source.id = pg.project_id
+		JOIN projects target ON target.curriculum_id = $2 AND target.identifier = source.identifier
+		WHERE source.curriculum_id = $1
+	`, sourceID, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to copy prerequisites: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO project_identifier_counters (curriculum_id, counter_key, last_value)
+		SELECT $2, counter_key, last_value
+		FROM project_identifier_counters
+		WHERE curriculum_id = $1
+	`, sourceID, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to copy identifier counters: %w", err)
+	}
+
+	return nil
+}
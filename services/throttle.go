@@ -0,0 +1,267 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginThrottlePolicy controls how failed logins slow down further attempts.
+// Every failure within FailureWindow of the previous one blocks the account
+// or IP for BackoffBase, doubling each time up to BackoffMax. Reaching the
+// failure limit locks it out for LockoutDuration instead.
+type LoginThrottlePolicy struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	BackoffBase        time.Duration
+	BackoffMax         time.Duration
+	LockoutDuration    time.Duration
+	FailureWindow      time.Duration
+}
+
+// LoginThrottleService tracks failed logins per account and per client IP.
+// Each attempt is reserved before the password is checked, counting it as a
+// failure up front, so concurrent guesses cannot all get past the check and a
+// blocked caller cannot make the server hash passwords for them. Times come
+// from now rather than the database so the policy can be exercised with a
+// fake clock.
+type LoginThrottleService struct {
+	db     *sql.DB
+	policy LoginThrottlePolicy
+	now    func() time.Time
+}
+
+func NewLoginThrottleService(db *sql.DB, policy LoginThrottlePolicy, now func() time.Time) *LoginThrottleService {
+	return &LoginThrottleService{db: db, policy: policy, now: now}
+}
+
+// Check returns how long the caller must wait before trying to log in to
+// email from ip, or zero if they may try now.
+func (s *LoginThrottleService) Check(email, ip string) (time.Duration, error) {
+	now := s.now().UTC()
+
+	var blockedUntil sql.NullTime
+	err := s.db.QueryRow(`
+		SELECT MAX(blocked_until)
+		FROM login_throttles
+		WHERE throttle_key IN ($1, $2)
+	`, accountThrottleKey(email), ipThrottleKey(ip)).Scan(&blockedUntil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query login throttle: %w", err)
+	}
+
+	if !blockedUntil.Valid {
+		return 0, nil
+	}
+
+	wait := asUTC(blockedUntil.Time).Sub(now)
+	if wait < 0 {
+		return 0, nil
+	}
+	return wait, nil
+}
+
+// Reserve counts a login attempt to email from ip as a failure before the
+// password is checked, and returns how long the caller must wait instead if
+// either is blocked. Counting and checking happen in one statement per key,
+// so of several concurrent attempts only the first gets through; the block
+// it sets turns the rest away. Call RecordFailure or RecordSuccess once the
+// password has been checked.
+func (s *LoginThrottleService) Reserve(email, ip string) (time.Duration, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := s.now().UTC()
+
+	// Always the account first, so concurrent attempts lock rows in the same
+	// order
+	keys := []struct {
+		key         string
+		maxFailures int
+	}{
+		{accountThrottleKey(email), s.policy.MaxAccountFailures},
+		{ipThrottleKey(ip), s.policy.MaxIPFailures},
+	}
+	for _, k := range keys {
+		// Rolling back returns the account's reservation if the IP is the
+		// one blocked
+		wait, err := s.reserve(tx, k.key, k.maxFailures, now)
+		if err != nil || wait > 0 {
+			return wait, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit login attempt: %w", err)
+	}
+
+	return 0, nil
+}
+
+// RecordFailure audits any lockout caused by a reserved attempt whose
+// password was wrong. The failure itself was counted by Reserve.
+func (s *LoginThrottleService) RecordFailure(email, ip string) error {
+	now := s.now().UTC()
+
+	failures, lockedUntil, err := s.lockout(accountThrottleKey(email), s.policy.MaxAccountFailures, now)
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		var userID int
+		err := s.db.QueryRow(`SELECT id FROM users WHERE LOWER(email) = $1`, normalizeEmail(email)).Scan(&userID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to query user: %w", err)
+		}
+
+		details := map[string]interface{}{"email": email, "ip": ip, "failures": failures, "locked_until": lockedUntil}
+		if err := recordAudit(s.db, userID, AuditActionAccountLockout, "user", userID, details); err != nil {
+			return err
+		}
+	}
+
+	failures, lockedUntil, err = s.lockout(ipThrottleKey(ip), s.policy.MaxIPFailures, now)
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil {
+		details := map[string]interface{}{"ip": ip, "failures": failures, "locked_until": lockedUntil}
+		if err := recordAudit(s.db, 0, AuditActionIPLockout, "ip", 0, details); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the account's failures and gives back the IP's
+// reservation. The IP's earlier failures are left alone, or an attacker
+// could reset them by signing in to an account of their own.
+func (s *LoginThrottleService) RecordSuccess(email, ip string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM login_throttles WHERE throttle_key = $1`, accountThrottleKey(email)); err != nil {
+		return fmt.Errorf("failed to clear login throttle: %w", err)
+	}
+
+	// The IP was not blocked when the attempt was reserved, so it is not
+	// blocked now either
+	_, err = tx.Exec(`
+		UPDATE login_throttles
+		SET failures = GREATEST(failures - 1, 0), blocked_until = LEAST(blocked_until, $2)
+		WHERE throttle_key = $1
+	`, ipThrottleKey(ip), s.now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to release login throttle: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM login_throttles WHERE throttle_key = $1 AND failures = 0`, ipThrottleKey(ip)); err != nil {
+		return fmt.Errorf("failed to clear login throttle: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit login success: %w", err)
+	}
+
+	return nil
+}
+
+// reserve counts one failure against key and blocks it accordingly, unless
+// key is blocked already, in which case it returns how long for. The upsert
+// locks the row, so concurrent callers wait for each other and see the block
+// the first one set.
+func (s *LoginThrottleService) reserve(tx *sql.Tx, key string, maxFailures int, now time.Time) (time.Duration, error) {
+	// Failures older than the window are forgotten and counting starts over
+	var failures int
+	err := tx.QueryRow(`
+		INSERT INTO login_throttles AS t (throttle_key, failures, last_failure_at, blocked_until)
+		VALUES ($1, 1, $2, $2)
+		ON CONFLICT (throttle_key) DO UPDATE
+		SET failures = CASE WHEN t.last_failure_at < $3 THEN 1 ELSE t.failures + 1 END,
+		    last_failure_at = $2
+		WHERE t.blocked_until <= $2
+		RETURNING failures
+	`, key, now, now.Add(-s.policy.FailureWindow)).Scan(&failures)
+	if err == sql.ErrNoRows {
+		// The row is locked, so the block cannot have moved since
+		var blockedUntil time.Time
+		err := tx.QueryRow(`SELECT blocked_until FROM login_throttles WHERE throttle_key = $1`, key).Scan(&blockedUntil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to query login throttle: %w", err)
+		}
+		return asUTC(blockedUntil).Sub(now), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve login attempt: %w", err)
+	}
+
+	blockedUntil := now.Add(s.backoff(failures))
+	if failures >= maxFailures {
+		blockedUntil = now.Add(s.policy.LockoutDuration)
+	}
+
+	if _, err := tx.Exec(`UPDATE login_throttles SET blocked_until = $1 WHERE throttle_key = $2`, blockedUntil, key); err != nil {
+		return 0, fmt.Errorf("failed to update login throttle: %w", err)
+	}
+
+	return 0, nil
+}
+
+// lockout returns the failure count of key and, when it is locked out,
+// until when.
+func (s *LoginThrottleService) lockout(key string, maxFailures int, now time.Time) (int, *time.Time, error) {
+	var failures int
+	var blockedUntil time.Time
+	err := s.db.QueryRow(`SELECT failures, blocked_until FROM login_throttles WHERE throttle_key = $1`, key).
+		Scan(&failures, &blockedUntil)
+	if err == sql.ErrNoRows {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query login throttle: %w", err)
+	}
+
+	blockedUntil = asUTC(blockedUntil)
+	if failures < maxFailures || !blockedUntil.After(now) {
+		return failures, nil, nil
+	}
+	return failures, &blockedUntil, nil
+}
+
+func (s *LoginThrottleService) backoff(failures int) time.Duration {
+	delay := s.policy.BackoffBase
+	for i := 1; i < failures && delay < s.policy.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > s.policy.BackoffMax {
+		return s.policy.BackoffMax
+	}
+	return delay
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + normalizeEmail(email)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// normalizeEmail folds case so an attacker cannot dodge the account limit by
+// varying it.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// asUTC reinterprets a TIMESTAMP read back from Postgres, which has no zone,
+// as the UTC time it was written as.
+func asUTC(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"curriculum-tracker/internal/testdb"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock tests move by hand.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func testThrottlePolicy() LoginThrottlePolicy {
+	return LoginThrottlePolicy{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      20,
+		BackoffBase:        time.Second,
+		BackoffMax:         8 * time.Second,
+		LockoutDuration:    15 * time.Minute,
+		FailureWindow:      time.Hour,
+	}
+}
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	s := NewLoginThrottleService(nil, testThrottlePolicy(), time.Now)
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 8 * time.Second},
+		{100, 8 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := s.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// newTestThrottle returns a throttle on a fake clock, with an email and IP
+// no other test uses.
+func newTestThrottle(t *testing.T, policy LoginThrottlePolicy) (*LoginThrottleService, *fakeClock, string, string) {
+	t.Helper()
+	db := testdb.Open(t)
+	// Whole seconds survive the round trip through TIMESTAMP columns exactly
+	clock := &fakeClock{now: time.Now().UTC().Truncate(time.Second)}
+	email := testdb.Email()
+	return NewLoginThrottleService(db, policy, clock.Now), clock, email, "ip-" + email
+}
+
+func checkWait(t *testing.T, s *LoginThrottleService, email, ip string, want time.Duration) {
+	t.Helper()
+	wait, err := s.Check(email, ip)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if wait != want {
+		t.Errorf("Check(%s, %s) = %s, want %s", email, ip, wait, want)
+	}
+}
+
+// recordFailure makes a failed login attempt, which must not be blocked.
+func recordFailure(t *testing.T, s *LoginThrottleService, email, ip string) {
+	t.Helper()
+	reserve(t, s, email, ip, 0)
+	if err := s.RecordFailure(email, ip); err != nil {
+		t.Fatalf("RecordFailure failed: %v", err)
+	}
+}
+
+func reserve(t *testing.T, s *LoginThrottleService, email, ip string, want time.Duration) {
+	t.Helper()
+	wait, err := s.Reserve(email, ip)
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if wait != want {
+		t.Errorf("Reserve(%s, %s) = %s, want %s", email, ip, wait, want)
+	}
+}
+
+func TestLoginThrottleBackoffDoubles(t *testing.T) {
+	policy := testThrottlePolicy()
+	policy.MaxAccountFailures = 10
+	s, clock, email, ip := newTestThrottle(t, policy)
+
+	checkWait(t, s, email, ip, 0)
+	for _, want := range []time.Duration{1, 2, 4, 8, 8, 8} {
+		recordFailure(t, s, email, ip)
+		checkWait(t, s, email, ip, want*time.Second)
+		clock.Advance(want * time.Second)
+	}
+}
+
+func TestLoginThrottleLockout(t *testing.T) {
+	policy := testThrottlePolicy()
+	policy.MaxAccountFailures = 3
+	s, clock, email, ip := newTestThrottle(t, policy)
+
+	recordFailure(t, s, email, ip)
+	clock.Advance(time.Second)
+	recordFailure(t, s, email, ip)
+	checkWait(t, s, email, ip, 2*time.Second)
+
+	clock.Advance(2 * time.Second)
+	recordFailure(t, s, email, ip)
+	checkWait(t, s, email, ip, policy.LockoutDuration)
+
+	// The account stays locked from anywhere, and case does not matter
+	checkWait(t, s, email, ip+"-other", policy.LockoutDuration)
+	reserve(t, s, " "+strings.ToUpper(email), ip+"-other", policy.LockoutDuration)
+
+	clock.Advance(policy.LockoutDuration)
+	checkWait(t, s, email, ip, 0)
+}
+
+func TestLoginThrottleWindowExpiry(t *testing.T) {
+	policy := testThrottlePolicy()
+	policy.MaxAccountFailures = 3
+	s, clock, email, ip := newTestThrottle(t, policy)
+
+	recordFailure(t, s, email, ip)
+	clock.Advance(time.Second)
+	recordFailure(t, s, email, ip)
+
+	// Failures further apart than the window start counting over, so the
+	// third is treated as the first rather than locking the account
+	clock.Advance(policy.FailureWindow + time.Second)
+	checkWait(t, s, email, ip, 0)
+
+	recordFailure(t, s, email, ip)
+	checkWait(t, s, email, ip, policy.BackoffBase)
+
+	// Within the window they add up again
+	clock.Advance(policy.FailureWindow - time.Second)
+	recordFailure(t, s, email, ip)
+	checkWait(t, s, email, ip, 2*policy.BackoffBase)
+}
+
+func TestLoginThrottleReserveBlocksUntilDone(t *testing.T) {
+	s, clock, email, ip := newTestThrottle(t, testThrottlePolicy())
+
+	// A second attempt while the first is being checked is turned away
+	reserve(t, s, email, ip, 0)
+	reserve(t, s, email, ip, time.Second)
+	reserve(t, s, email, ip+"-other", time.Second)
+	reserve(t, s, testdb.Email(), ip, time.Second)
+
+	// Being turned away is not a failure
+	clock.Advance(time.Second)
+	reserve(t, s, email, ip, 0)
+	checkWait(t, s, email, ip, 2*time.Second)
+}
+
+func TestLoginThrottleRecordSuccess(t *testing.T) {
+	s, clock, email, ip := newTestThrottle(t, testThrottlePolicy())
+	otherEmail := testdb.Email()
+
+	recordFailure(t, s, email, ip)
+	clock.Advance(time.Second)
+	recordFailure(t, s, email, ip)
+	clock.Advance(2 * time.Second)
+
+	reserve(t, s, email, ip, 0)
+	if err := s.RecordSuccess(email, ip); err != nil {
+		t.Fatalf("RecordSuccess failed: %v", err)
+	}
+
+	// The account is cleared, and the IP is no longer blocked by the
+	// successful attempt
+	checkWait(t, s, email, ip+"-other", 0)
+	checkWait(t, s, otherEmail, ip, 0)
+
+	// But keeps counting its earlier failures
+	recordFailure(t, s, otherEmail, ip)
+	checkWait(t, s, otherEmail, ip+"-other", time.Second)
+	checkWait(t, s, testdb.Email(), ip, 4*time.Second)
+}
+
+func TestLoginThrottleConcurrentAttempts(t *testing.T) {
+	s, _, email, ip := newTestThrottle(t, testThrottlePolicy())
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	waits := make(chan time.Duration, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, err := s.Reserve(email, ip)
+			if err != nil {
+				t.Errorf("Reserve failed: %v", err)
+				return
+			}
+			waits <- wait
+		}()
+	}
+	wg.Wait()
+	close(waits)
+
+	allowed := 0
+	for wait := range waits {
+		if wait == 0 {
+			allowed++
+		}
+	}
+	if allowed != 1 {
+		t.Errorf("%d of %d concurrent attempts were allowed, want 1", allowed, attempts)
+	}
+
+	// Only the allowed attempt counts as a failure
+	var failures int
+	err := s.db.QueryRow(`SELECT failures FROM login_throttles WHERE throttle_key = $1`, accountThrottleKey(email)).Scan(&failures)
+	if err != nil {
+		t.Fatalf("failed to query throttle: %v", err)
+	}
+	if failures != 1 {
+		t.Errorf("account failures = %d, want 1", failures)
+	}
+}
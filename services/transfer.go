@@ -0,0 +1,299 @@
+package services
+
+import (
+	"curriculum-tracker/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var validProgressStatuses = map[string]bool{
+	models.StatusNotStarted: true,
+	models.StatusInProgress: true,
+	models.StatusCompleted:  true,
+	models.StatusOnHold:     true,
+	models.StatusAbandoned:  true,
+}
+
+var validNoteTypes = map[string]bool{
+	models.NoteTypeNote:       true,
+	models.NoteTypeReflection: true,
+	models.NoteTypeLearning:   true,
+	models.NoteTypeQuestion:   true,
+}
+
+func (s *CurriculumService) ExportCurriculum(userID, curriculumID int, opts models.ExportOptions) (*models.CurriculumDocument, error) {
+	curriculum, err := s.GetCurriculumByID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := s.projectService.GetProjectsByCurriculumID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &models.CurriculumDocument{
+		Version:    models.CurriculumDocumentVersion,
+		ExportedAt: time.Now().UTC(),
+		Curriculum: models.DocumentCurriculum{
+			Name:        curriculum.Name,
+			Description: curriculum.Description,
+		},
+		Projects: make([]models.DocumentProject, 0, len(projects)),
+	}
+
+	byProjectID := make(map[int]int, len(projects))
+	for _, p := range projects {
+		dp := models.DocumentProject{
+			Identifier:         p.Identifier,
+			Name:               p.Name,
+			Description:        p.Description,
+			LearningObjectives: append([]string{}, p.LearningObjectives...),
+			EstimatedTime:      p.EstimatedTime,
+			Prerequisites:      append([]string{}, p.Prerequisites...),
+			ProjectType:        p.ProjectType,
+			BranchGroup:        p.BranchGroup,
+			PositionOrder:      p.PositionOrder,
+		}
+
+		if opts.IncludeProgress && p.Progress != nil {
+			dp.Progress = &models.DocumentProgress{
+				Status:               p.Progress.Status,
+				CompletionPercentage: p.Progress.CompletionPercentage,
+			}
+			if p.Progress.StartedAt.Valid {
+				dp.Progress.StartedAt = &p.Progress.StartedAt.Time
+			}
+			if p.Progress.CompletedAt.Valid {
+				dp.Progress.CompletedAt = &p.Progress.CompletedAt.Time
+			}
+		}
+
+		byProjectID[p.ID] = len(doc.Projects)
+		doc.Projects = append(doc.Projects, dp)
+	}
+
+	if opts.IncludeNotes {
+		query := `
+			SELECT n.project_id, n.title, n.content, n.note_type, n.created_at
+			FROM notes n
+			JOIN projects p ON n.project_id = p.id
+			WHERE n.user_id = $1 AND p.curriculum_id = $2
+			ORDER BY n.created_at
+		`
+
+		rows, err := s.db.Query(query, userID, curriculumID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query notes: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var projectID int
+			var note models.DocumentNote
+			if err := rows.Scan(&projectID, &note.Title, &note.Content, &note.NoteType, &note.CreatedAt); err != nil {
+				return nil, fmt.Errorf("failed to scan note: %w", err)
+			}
+			if i, exists := byProjectID[projectID]; exists {
+				doc.Projects[i].Notes = append(doc.Projects[i].Notes, note)
+			}
+		}
+	}
+
+	if opts.IncludeTimeEntries {
+		query := `
+			SELECT te.project_id, te.minutes, te.description, te.date
+			FROM time_entries te
+			JOIN projects p ON te.project_id = p.id
+			WHERE te.user_id = $1 AND p.curriculum_id = $2
+			ORDER BY te.date, te.created_at
+		`
+
+		rows, err := s.db.Query(query, userID, curriculumID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query time entries: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var projectID int
+			var entry models.DocumentTimeEntry
+			var date time.Time
+			if err := rows.Scan(&projectID, &entry.Minutes, &entry.Description, &date); err != nil {
+				return nil, fmt.Errorf("failed to scan time entry: %w", err)
+			}
+			entry.Date = date.Format("2006-01-02")
+			if i, exists := byProjectID[projectID]; exists {
+				doc.Projects[i].TimeEntries = append(doc.Projects[i].TimeEntries, entry)
+			}
+		}
+	}
+
+	return doc, nil
+}
+
+func (s *CurriculumService) ImportCurriculum(userID int, doc models.CurriculumDocument) (*models.ImportResult, error) {
+	if err := validateCurriculumDocument(doc); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var curriculumID int
+	err = tx.QueryRow(
+		`INSERT INTO curricula (user_id, name, description) VALUES ($1, $2, $3) RETURNING id`,
+		userID, doc.Curriculum.Name, doc.Curriculum.Description,
+	).Scan(&curriculumID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create curriculum: %w", err)
+	}
+
+	if err := addCurriculumMember(tx, curriculumID, userID, models.MemberRoleOwner); err != nil {
+		return nil, err
+	}
+
+	identifierMap, err := importProjects(tx, userID, curriculumID, doc.Projects)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	curriculum, err := s.GetCurriculumByID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	curriculum.Projects, err = s.projectService.GetProjectsByCurriculumID(userID, curriculumID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ImportResult{
+		Curriculum:    curriculum,
+		IdentifierMap: identifierMap,
+	}, nil
+}
+
+func validateCurriculumDocument(doc models.CurriculumDocument) error {
+	if doc.Version == 0 {
+		return validationErrorf("document version is required")
+	}
+	if doc.Version > models.CurriculumDocumentVersion {
+		return validationErrorf("unsupported document version: %d", doc.Version)
+	}
+	if strings.TrimSpace(doc.Curriculum.Name) == "" {
+		return validationErrorf("curriculum name is required")
+	}
+
+	for _, p := range doc.Projects {
+		if strings.TrimSpace(p.Identifier) == "" {
+			return validationErrorf("project %q has no identifier", p.Name)
+		}
+		if p.Progress != nil && !validProgressStatuses[p.Progress.Status] {
+			return validationErrorf("project %s has invalid progress status: %s", p.Identifier, p.Progress.Status)
+		}
+		if p.Progress != nil && (p.Progress.CompletionPercentage < 0 || p.Progress.CompletionPercentage > 100) {
+			return validationErrorf("project %s has completion percentage outside 0 to 100", p.Identifier)
+		}
+		for _, note := range p.Notes {
+			if note.Content == "" || !validNoteTypes[note.NoteType] {
+				return validationErrorf("project %s has an invalid note", p.Identifier)
+			}
+		}
+		for _, entry := range p.TimeEntries {
+			if _, err := time.Parse("2006-01-02", entry.Date); err != nil || entry.Minutes <= 0 {
+				return validationErrorf("project %s has an invalid time entry", p.Identifier)
+			}
+		}
+	}
+
+	return validateProjectDrafts(documentDrafts(doc.Projects), nil)
+}
+
+func documentDrafts(items []models.DocumentProject) []projectDraft {
+	drafts := make([]projectDraft, 0, len(items))
+	for _, item := range items {
+		drafts = append(drafts, projectDraft{
+			key: strings.TrimSpace(item.Identifier),
+			req: models.CreateProjectRequest{
+				Name:               item.Name,
+				Description:        item.Description,
+				LearningObjectives: item.LearningObjectives,
+				EstimatedTime:      item.EstimatedTime,
+				Prerequisites:      item.Prerequisites,
+				ProjectType:        item.ProjectType,
+				BranchGroup:        item.BranchGroup,
+				PositionOrder:      item.PositionOrder,
+			},
+		})
+	}
+	return drafts
+}
+
+// importProjects inserts document projects into a curriculum along with any
+// user activity they carry. It returns a map from document identifiers to
+// the generated ones.
+func importProjects(tx *sql.Tx, userID, curriculumID int, items []models.DocumentProject) (map[string]string, error) {
+	projectIDs, identifierMap, err := insertProjects(tx, curriculumID, documentDrafts(items))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, item := range items {
+		if err := importProjectActivity(tx, userID, projectIDs[i], item); err != nil {
+			return nil, err
+		}
+	}
+
+	return identifierMap, nil
+}
+
+func importProjectActivity(tx *sql.Tx, userID, projectID int, item models.DocumentProject) error {
+	if item.Progress != nil {
+		_, err := tx.Exec(`
+			INSERT INTO progress (user_id, project_id, status, completion_percentage, started_at, completed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, userID, projectID, item.Progress.Status,
+			normalizeCompletion(item.Progress.Status, item.Progress.CompletionPercentage),
+			item.Progress.StartedAt, item.Progress.CompletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import progress: %w", err)
+		}
+	}
+
+	for _, note := range item.Notes {
+		createdAt := note.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO notes (user_id, project_id, title, content, note_type, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $6)
+		`, userID, projectID, note.Title, note.Content, note.NoteType, createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to import note: %w", err)
+		}
+	}
+
+	for _, entry := range item.TimeEntries {
+		_, err := tx.Exec(`
+			INSERT INTO time_entries (user_id, project_id, minutes, description, date)
+			VALUES ($1, $2, $3, $4, $5)
+		`, userID, projectID, entry.Minutes, entry.Description, entry.Date)
+		if err != nil {
+			return fmt.Errorf("failed to import time entry: %w", err)
+		}
+	}
+
+	return nil
+}
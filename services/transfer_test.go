@@ -0,0 +1,102 @@
+package services
+
+import (
+	"curriculum-tracker/internal/testdb"
+	"curriculum-tracker/models"
+	"reflect"
+	"testing"
+)
+
+func TestValidateCurriculumDocumentProgress(t *testing.T) {
+	tests := []struct {
+		percentage int
+		wantErr    bool
+	}{
+		{percentage: 0},
+		{percentage: 100},
+		{percentage: -1, wantErr: true},
+		{percentage: 101, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		doc := models.CurriculumDocument{
+			Version:    models.CurriculumDocumentVersion,
+			Curriculum: models.DocumentCurriculum{Name: "Imported"},
+			Projects: []models.DocumentProject{{
+				Identifier:  "R1",
+				Name:        "Root",
+				ProjectType: models.ProjectTypeRoot,
+				Progress: &models.DocumentProgress{
+					Status:               models.StatusInProgress,
+					CompletionPercentage: tt.percentage,
+				},
+			}},
+		}
+
+		err := validateCurriculumDocument(doc)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateCurriculumDocument with %d%% = %v, want error %v", tt.percentage, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExportImportRoundTripBranches(t *testing.T) {
+	db := testdb.Open(t)
+	user, curriculum := createTestCurriculum(t, db)
+	projects := NewProjectService(db)
+	curricula := NewCurriculumService(db)
+
+	group := func(n int) *int { return &n }
+	creates := []models.CreateProjectRequest{
+		{Name: "Grouped", ProjectType: models.ProjectTypeLowerBranch, BranchGroup: group(1), PositionOrder: 2},
+		// Skips number 1, which group 1 uses, but comes before the group in
+		// position order
+		{Name: "Ungrouped", ProjectType: models.ProjectTypeLowerBranch, PositionOrder: 1},
+		{Name: "Later group", ProjectType: models.ProjectTypeLowerBranch, BranchGroup: group(3), PositionOrder: 3},
+		{Name: "Ungrouped after", ProjectType: models.ProjectTypeLowerBranch, PositionOrder: 4},
+		{Name: "Middle", ProjectType: models.ProjectTypeMiddleBranch, Prerequisites: []string{"LB1", "LB2"}, PositionOrder: 5},
+	}
+	for _, req := range creates {
+		if _, err := projects.CreateProject(user.ID, curriculum.ID, req); err != nil {
+			t.Fatalf("CreateProject(%s) failed: %v", req.Name, err)
+		}
+	}
+
+	doc, err := curricula.ExportCurriculum(user.ID, curriculum.ID, models.ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportCurriculum failed: %v", err)
+	}
+
+	result, err := curricula.ImportCurriculum(user.ID, *doc)
+	if err != nil {
+		t.Fatalf("ImportCurriculum failed: %v", err)
+	}
+
+	for from, to := range result.IdentifierMap {
+		if from != to {
+			t.Errorf("identifier %s was imported as %s", from, to)
+		}
+	}
+
+	imported, err := curricula.ExportCurriculum(user.ID, result.Curriculum.ID, models.ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportCurriculum of the import failed: %v", err)
+	}
+
+	type shape struct {
+		Identifier    string
+		Prerequisites []string
+		BranchGroup   *int
+		PositionOrder int
+	}
+	shapes := func(doc *models.CurriculumDocument) []shape {
+		out := make([]shape, 0, len(doc.Projects))
+		for _, p := range doc.Projects {
+			out = append(out, shape{p.Identifier, p.Prerequisites, p.BranchGroup, p.PositionOrder})
+		}
+		return out
+	}
+	if got, want := shapes(imported), shapes(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("imported projects = %+v, want %+v", got, want)
+	}
+}
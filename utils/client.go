@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address of the client behind a request.
+// trustedProxies is the number of reverse proxies in front of the API that
+// append to X-Forwarded-For. Each appends the address it received the
+// request from, so the client is the entry trustedProxies from the right;
+// anything further left came from the client and can say anything. With no
+// trusted proxies, or a header too short to have passed through all of
+// them, the address of the connection is used instead.
+func ClientIP(r *http.Request, trustedProxies int) string {
+	if trustedProxies > 0 {
+		var entries []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			entries = append(entries, strings.Split(header, ",")...)
+		}
+		if len(entries) >= trustedProxies {
+			if ip := net.ParseIP(strings.TrimSpace(entries[len(entries)-trustedProxies])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
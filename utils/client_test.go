@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		forwarded      []string
+		realIP         string
+		trustedProxies int
+		want           string
+	}{
+		{name: "no proxy", remoteAddr: "203.0.113.7:4000", want: "203.0.113.7"},
+		{name: "no proxy ignores forwarded", remoteAddr: "203.0.113.7:4000", forwarded: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "one proxy", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.1"}, trustedProxies: 1, want: "198.51.100.1"},
+		{name: "one proxy ignores spoofed entries", remoteAddr: "10.0.0.2:4000", forwarded: []string{"1.2.3.4, 5.6.7.8, 198.51.100.1"}, trustedProxies: 1, want: "198.51.100.1"},
+		{name: "two proxies", remoteAddr: "10.0.0.3:4000", forwarded: []string{"1.2.3.4, 198.51.100.1, 10.0.0.2"}, trustedProxies: 2, want: "198.51.100.1"},
+		{name: "several headers", remoteAddr: "10.0.0.3:4000", forwarded: []string{"1.2.3.4", "198.51.100.1, 10.0.0.2"}, trustedProxies: 2, want: "198.51.100.1"},
+		{name: "header shorter than the proxies", remoteAddr: "10.0.0.3:4000", forwarded: []string{"198.51.100.1"}, trustedProxies: 2, want: "10.0.0.3"},
+		{name: "invalid entry", remoteAddr: "10.0.0.2:4000", forwarded: []string{"198.51.100.1, garbage"}, trustedProxies: 1, want: "10.0.0.2"},
+		{name: "no header", remoteAddr: "10.0.0.2:4000", trustedProxies: 1, want: "10.0.0.2"},
+		{name: "real ip is not read", remoteAddr: "10.0.0.2:4000", realIP: "198.51.100.1", trustedProxies: 1, want: "10.0.0.2"},
+		{name: "ipv6", remoteAddr: "[2001:db8::2]:4000", forwarded: []string{" 2001:db8::1 "}, trustedProxies: 1, want: "2001:db8::1"},
+		{name: "remote address without port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, forwarded := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			if got := ClientIP(r, tt.trustedProxies); got != tt.want {
+				t.Errorf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+const maxDocumentSize = 10 << 20
+
+// RequestFormat picks the document format from the request body's
+// Content-Type, defaulting to JSON.
+func RequestFormat(r *http.Request) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+func ParseDocument(r *http.Request, v interface{}) error {
+	body := io.LimitReader(r.Body, maxDocumentSize)
+
+	if RequestFormat(r) == FormatYAML {
+		return yaml.NewDecoder(body).Decode(v)
+	}
+
+	return json.NewDecoder(body).Decode(v)
+}
+
+// WriteDocument writes v as a downloadable JSON or YAML document without the
+// standard response envelope so it can be fed straight back into an import.
+func WriteDocument(w http.ResponseWriter, format, filename string, v interface{}) error {
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+
+	if format == FormatYAML {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.WriteHeader(http.StatusOK)
+		encoder := yaml.NewEncoder(w)
+		defer encoder.Close()
+		return encoder.Encode(v)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Estimates are effort, not calendar time: a day is a working day and a week
+// is a working week.
+const (
+	MinutesPerHour  = 60
+	MinutesPerDay   = 8 * MinutesPerHour
+	MinutesPerWeek  = 5 * MinutesPerDay
+	MinutesPerMonth = 4 * MinutesPerWeek
+)
+
+var estimatePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-z]*)\s*(?:(?:-|–|to)\s*(\d+(?:\.\d+)?)\s*([a-z]+))?$`)
+
+var compoundEstimatePattern = regexp.MustCompile(`^(?:\d+(?:\.\d+)?\s*[a-z]+\s*(?:,|and)?\s*)+$`)
+
+var compoundEstimatePart = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]+)`)
+
+var estimateUnits = map[string]int{
+	"m":       1,
+	"min":     1,
+	"mins":    1,
+	"minute":  1,
+	"minutes": 1,
+	"h":       MinutesPerHour,
+	"hr":      MinutesPerHour,
+	"hrs":     MinutesPerHour,
+	"hour":    MinutesPerHour,
+	"hours":   MinutesPerHour,
+	"d":       MinutesPerDay,
+	"day":     MinutesPerDay,
+	"days":    MinutesPerDay,
+	"w":       MinutesPerWeek,
+	"wk":      MinutesPerWeek,
+	"wks":     MinutesPerWeek,
+	"week":    MinutesPerWeek,
+	"weeks":   MinutesPerWeek,
+	"month":   MinutesPerMonth,
+	"months":  MinutesPerMonth,
+}
+
+// ParseEstimatedTime converts free-form estimates such as "45m", "10h",
+// "1 week", "2-3 weeks" or "1h 30m" into a minimum and maximum number of
+// minutes. A leading "~" or "about" is ignored.
+func ParseEstimatedTime(text string) (int, int, error) {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	normalized = strings.TrimSpace(strings.TrimPrefix(normalized, "~"))
+	normalized = strings.TrimSpace(strings.TrimPrefix(normalized, "about "))
+
+	match := estimatePattern.FindStringSubmatch(normalized)
+	if match == nil {
+		if compoundEstimatePattern.MatchString(normalized) {
+			return parseCompoundEstimate(text, normalized)
+		}
+		return 0, 0, fmt.Errorf("unrecognized estimated time: %q", text)
+	}
+
+	low, lowUnit, high, highUnit := match[1], match[2], match[3], match[4]
+	if high == "" {
+		high, highUnit = low, lowUnit
+	}
+	if lowUnit == "" {
+		lowUnit = highUnit
+	}
+
+	minMinutes, err := estimateMinutes(low, lowUnit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unrecognized estimated time: %q", text)
+	}
+	maxMinutes, err := estimateMinutes(high, highUnit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unrecognized estimated time: %q", text)
+	}
+
+	if minMinutes > maxMinutes {
+		return 0, 0, fmt.Errorf("estimated time range is reversed: %q", text)
+	}
+
+	return minMinutes, maxMinutes, nil
+}
+
+func parseCompoundEstimate(text, normalized string) (int, int, error) {
+	total := 0
+	for _, part := range compoundEstimatePart.FindAllStringSubmatch(normalized, -1) {
+		minutes, err := estimateMinutes(part[1], part[2])
+		if err != nil {
+			return 0, 0, fmt.Errorf("unrecognized estimated time: %q", text)
+		}
+		total += minutes
+	}
+
+	return total, total, nil
+}
+
+func estimateMinutes(value, unit string) (int, error) {
+	perUnit, ok := estimateUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit: %q", unit)
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(math.Round(amount * float64(perUnit))), nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import "testing"
+
+func TestParseEstimatedTime(t *testing.T) {
+	tests := []struct {
+		text    string
+		min     int
+		max     int
+		wantErr bool
+	}{
+		// Single values and units
+		{text: "45m", min: 45, max: 45},
+		{text: "45 mins", min: 45, max: 45},
+		{text: "10h", min: 600, max: 600},
+		{text: "2 hrs", min: 120, max: 120},
+		{text: "1.5h", min: 90, max: 90},
+		{text: "3 days", min: 3 * MinutesPerDay, max: 3 * MinutesPerDay},
+		{text: "1 week", min: MinutesPerWeek, max: MinutesPerWeek},
+		{text: "2wks", min: 2 * MinutesPerWeek, max: 2 * MinutesPerWeek},
+		{text: "1 month", min: MinutesPerMonth, max: MinutesPerMonth},
+		{text: "0h", min: 0, max: 0},
+
+		// Ranges
+		{text: "2-3 weeks", min: 2 * MinutesPerWeek, max: 3 * MinutesPerWeek},
+		{text: "2 - 3 weeks", min: 2 * MinutesPerWeek, max: 3 * MinutesPerWeek},
+		{text: "2–3 days", min: 2 * MinutesPerDay, max: 3 * MinutesPerDay},
+		{text: "2 to 3 days", min: 2 * MinutesPerDay, max: 3 * MinutesPerDay},
+		{text: "30m-2h", min: 30, max: 120},
+		{text: "2 weeks to 1 month", min: 2 * MinutesPerWeek, max: MinutesPerMonth},
+		{text: "1-1 days", min: MinutesPerDay, max: MinutesPerDay},
+
+		// Compound durations
+		{text: "1h 30m", min: 90, max: 90},
+		{text: "1h30m", min: 90, max: 90},
+		{text: "1h, 30m", min: 90, max: 90},
+		{text: "1 hour and 30 minutes", min: 90, max: 90},
+		{text: "1 day 4 hours", min: MinutesPerDay + 240, max: MinutesPerDay + 240},
+
+		// Text as it is found in existing projects
+		{text: "  2 Hours ", min: 120, max: 120},
+		{text: "~2h", min: 120, max: 120},
+		{text: "~ 2h", min: 120, max: 120},
+		{text: "About 1 week", min: MinutesPerWeek, max: MinutesPerWeek},
+		{text: "WEEKS 2", wantErr: true},
+
+		// Malformed input
+		{text: "", wantErr: true},
+		{text: "   ", wantErr: true},
+		{text: "soon", wantErr: true},
+		{text: "5", wantErr: true},
+		{text: "2-3", wantErr: true},
+		{text: "5 parsecs", wantErr: true},
+		{text: "1h 30x", wantErr: true},
+		{text: "-2h", wantErr: true},
+		{text: "3-2 days", wantErr: true},
+		{text: "2 weeks - 1 day", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			min, max, err := ParseEstimatedTime(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseEstimatedTime(%q) = %d, %d, want error", tt.text, min, max)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEstimatedTime(%q) returned error: %v", tt.text, err)
+			}
+			if min != tt.min || max != tt.max {
+				t.Errorf("ParseEstimatedTime(%q) = %d, %d, want %d, %d", tt.text, min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
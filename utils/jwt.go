@@ -2,22 +2,44 @@ package utils
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Claims identify the user and the session an access token was issued for.
+// The registered ID claim (jti) identifies the token itself so it can be
+// revoked before it expires. Scope lists what the token may be used for,
+// space-separated as in OAuth.
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	Scope     string `json:"scope"`
+	SessionID int    `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int, email string, secret string, duration time.Duration) (string, error) {
+// Scopes returns the token's scopes as a list.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+func GenerateToken(userID int, email, role string, scopes []string, sessionID int, secret string, duration time.Duration) (string, error) {
+	tokenID, err := generateTokenID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		Scope:     strings.Join(scopes, " "),
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
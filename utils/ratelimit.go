@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is a token bucket holding up to Burst requests, refilled at a
+// rate of Burst requests per Period. The zero value means no limit.
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseRateLimit reads a limit written as "<requests>/<period>", such as
+// "100/1m", or "off" for no limit.
+func ParseRateLimit(s string) (RateLimit, error) {
+	if s == "off" {
+		return RateLimit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	burst, err := strconv.Atoi(requests)
+	if err != nil || burst <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	return RateLimit{Burst: burst, Period: duration}, nil
+}
+
+func (l RateLimit) Enabled() bool {
+	return l.Burst > 0
+}
+
+// Take refills a bucket that held tokens elapsed ago and spends one token
+// from it if it can, returning the tokens left and whether one was spent.
+func (l RateLimit) Take(tokens float64, elapsed time.Duration) (float64, bool) {
+	if elapsed > 0 {
+		tokens += elapsed.Seconds() * l.perSecond()
+	}
+	tokens = math.Min(tokens, float64(l.Burst))
+
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+// Until returns how long a bucket holding tokens takes to refill to want.
+func (l RateLimit) Until(tokens, want float64) time.Duration {
+	if tokens >= want {
+		return 0
+	}
+	return time.Duration((want - tokens) / l.perSecond() * float64(time.Second))
+}
+
+func (l RateLimit) perSecond() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    RateLimit
+		wantErr bool
+	}{
+		{text: "100/1m", want: RateLimit{Burst: 100, Period: time.Minute}},
+		{text: "20/30s", want: RateLimit{Burst: 20, Period: 30 * time.Second}},
+		{text: "1/1h", want: RateLimit{Burst: 1, Period: time.Hour}},
+		{text: "off", want: RateLimit{}},
+		{text: "", wantErr: true},
+		{text: "100", wantErr: true},
+		{text: "100/", wantErr: true},
+		{text: "/1m", wantErr: true},
+		{text: "0/1m", wantErr: true},
+		{text: "-5/1m", wantErr: true},
+		{text: "five/1m", wantErr: true},
+		{text: "100/0s", wantErr: true},
+		{text: "100/-1m", wantErr: true},
+		{text: "100/minute", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRateLimit(%q) = %+v, want error", tt.text, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRateLimit(%q) returned error: %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRateLimit(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitEnabled(t *testing.T) {
+	if (RateLimit{}).Enabled() {
+		t.Error("zero RateLimit is enabled")
+	}
+	if !(RateLimit{Burst: 1, Period: time.Second}).Enabled() {
+		t.Error("RateLimit with a burst is not enabled")
+	}
+}
+
+func TestRateLimitTake(t *testing.T) {
+	// 10 requests per 10s refills one token a second
+	limit := RateLimit{Burst: 10, Period: 10 * time.Second}
+
+	tests := []struct {
+		name        string
+		tokens      float64
+		elapsed     time.Duration
+		wantTokens  float64
+		wantAllowed bool
+	}{
+		{name: "full bucket", tokens: 10, wantTokens: 9, wantAllowed: true},
+		{name: "last token", tokens: 1, wantTokens: 0, wantAllowed: true},
+		{name: "empty bucket", tokens: 0, wantTokens: 0, wantAllowed: false},
+		{name: "partial token", tokens: 0.5, wantTokens: 0.5, wantAllowed: false},
+		{name: "refill to one token", tokens: 0, elapsed: time.Second, wantTokens: 0, wantAllowed: true},
+		{name: "refill short of a token", tokens: 0, elapsed: 500 * time.Millisecond, wantTokens: 0.5, wantAllowed: false},
+		{name: "refill several tokens", tokens: 2, elapsed: 3 * time.Second, wantTokens: 4, wantAllowed: true},
+		{name: "refill caps at burst", tokens: 5, elapsed: time.Hour, wantTokens: 9, wantAllowed: true},
+		{name: "clock going backwards", tokens: 3, elapsed: -time.Minute, wantTokens: 2, wantAllowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, allowed := limit.Take(tt.tokens, tt.elapsed)
+			if tokens != tt.wantTokens || allowed != tt.wantAllowed {
+				t.Errorf("Take(%v, %s) = %v, %v, want %v, %v", tt.tokens, tt.elapsed, tokens, allowed, tt.wantTokens, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestRateLimitBurst(t *testing.T) {
+	limit := RateLimit{Burst: 3, Period: time.Minute}
+
+	tokens := float64(limit.Burst)
+	for i := 0; i < limit.Burst; i++ {
+		var allowed bool
+		tokens, allowed = limit.Take(tokens, 0)
+		if !allowed {
+			t.Fatalf("request %d was refused within the burst", i+1)
+		}
+	}
+
+	if _, allowed := limit.Take(tokens, 0); allowed {
+		t.Error("request beyond the burst was allowed")
+	}
+}
+
+func TestRateLimitUntil(t *testing.T) {
+	limit := RateLimit{Burst: 10, Period: 10 * time.Second}
+
+	tests := []struct {
+		tokens float64
+		want   float64
+		wait   time.Duration
+	}{
+		{tokens: 0, want: 1, wait: time.Second},
+		{tokens: 0.25, want: 1, wait: 750 * time.Millisecond},
+		{tokens: 0, want: 10, wait: 10 * time.Second},
+		{tokens: 4, want: 10, wait: 6 * time.Second},
+		{tokens: 1, want: 1, wait: 0},
+		{tokens: 10, want: 10, wait: 0},
+	}
+
+	for _, tt := range tests {
+		if got := limit.Until(tt.tokens, tt.want); got != tt.wait {
+			t.Errorf("Until(%v, %v) = %s, want %s", tt.tokens, tt.want, got, tt.wait)
+		}
+	}
+}
@@ -29,6 +29,16 @@ func WriteError(w http.ResponseWriter, status int, message string) {
 	})
 }
 
+func WriteErrorWithData(w http.ResponseWriter, status int, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{
+		Success: false,
+		Data:    data,
+		Error:   message,
+	})
+}
+
 func ParseJSON(r *http.Request, v interface{}) error {
 	return json.NewDecoder(r.Body).Decode(v)
 }